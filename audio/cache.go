@@ -0,0 +1,76 @@
+package audio
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// CacheAsset returns the path of an embedded asset inside cacheDir, writing
+// it only if it isn't there yet. Files live in a directory named after a
+// hash of their content, so a new build with different assets gets new
+// files, file names stay readable, and repeated launches cost no I/O beyond
+// a stat.
+func CacheAsset(cacheDir string, assetsFS embed.FS, assetPath string) (string, error) {
+	data, err := fs.ReadFile(assetsFS, assetPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read embedded %s: %w", assetPath, err)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:8])
+	base := path.Base(assetPath)
+	hashDir := filepath.Join(cacheDir, hash)
+	cachedPath := filepath.Join(hashDir, base)
+
+	if info, err := os.Stat(cachedPath); err == nil && info.Size() == int64(len(data)) {
+		return cachedPath, nil
+	}
+
+	if err := os.MkdirAll(hashDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temp file in the same directory and rename, so a concurrent
+	// instance never sees a partially written file
+	tmpFile, err := os.CreateTemp(hashDir, ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create cache file: %w", err)
+	}
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("failed to write cache file: %w", err)
+	}
+	tmpFile.Close()
+
+	if err := os.Rename(tmpFile.Name(), cachedPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("failed to store cache file: %w", err)
+	}
+
+	pruneCache(cacheDir, base, hash)
+	return cachedPath, nil
+}
+
+// pruneCache removes older cached versions of the same asset, and their
+// directories once empty
+func pruneCache(cacheDir, base, keepHash string) {
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == keepHash {
+			continue
+		}
+		dir := filepath.Join(cacheDir, entry.Name())
+		os.Remove(filepath.Join(dir, base))
+		os.Remove(dir) // Only succeeds when empty
+	}
+}
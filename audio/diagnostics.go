@@ -0,0 +1,120 @@
+package audio
+
+import (
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// Backend describes an external player binary the audio package can use
+type Backend struct {
+	Name  string // Binary name, e.g. "ffplay"
+	Path  string // Resolved path, empty if not found on PATH
+	Found bool
+}
+
+// Diagnostics is a snapshot of the audio subsystem used by `zoneout doctor`
+// and the in-TUI status line
+type Diagnostics struct {
+	Backends        []Backend // Candidate players in order of preference
+	Player          string    // First backend that was found, empty if none
+	PlayerPath      string
+	EmbeddedLoaded  bool   // Whether the embedded whitenoise was extracted
+	EmbeddedPath    string // Where the embedded whitenoise was extracted to
+	AvailableSounds int
+	LastError       error
+	LastErrorAt     time.Time
+}
+
+// candidateBackends returns the player binaries tried by PlayMP3, in order
+func candidateBackends() []string {
+	if runtime.GOOS == "darwin" {
+		return []string{"afplay"}
+	}
+	return []string{"ffplay", "play"}
+}
+
+// DetectBackends looks up every candidate player on PATH
+func DetectBackends() []Backend {
+	var backends []Backend
+	for _, name := range candidateBackends() {
+		b := Backend{Name: name}
+		if path, err := exec.LookPath(name); err == nil {
+			b.Path = path
+			b.Found = true
+		}
+		backends = append(backends, b)
+	}
+	return backends
+}
+
+// Diagnostics reports which player would be used, whether the embedded
+// assets are cached and the last playback error
+func (ap *AudioPlayer) Diagnostics() Diagnostics {
+	backends := DetectBackends()
+
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+
+	d := Diagnostics{
+		Backends:        backends,
+		EmbeddedLoaded:  ap.embeddedFile != "",
+		EmbeddedPath:    ap.embeddedFile,
+		AvailableSounds: len(ap.availableMP3s),
+		LastError:       ap.lastError,
+		LastErrorAt:     ap.lastErrorAt,
+	}
+	for _, b := range backends {
+		if b.Found {
+			d.Player = b.Name
+			d.PlayerPath = b.Path
+			break
+		}
+	}
+	return d
+}
+
+// LastError returns the most recent playback error, or nil
+func (ap *AudioPlayer) LastError() error {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	return ap.lastError
+}
+
+// TakeError returns a playback error that has not been reported yet and
+// clears it, so the UI shows each failure once
+func (ap *AudioPlayer) TakeError() error {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+
+	err := ap.pendingError
+	ap.pendingError = nil
+	return err
+}
+
+// PlaySoundEffectSync plays a sound effect and waits for it to finish,
+// returning any error. Used by `zoneout doctor` to test playback.
+func (ap *AudioPlayer) PlaySoundEffectSync(filePath string) error {
+	cmd := soundEffectCommand(filePath, ap.GetVolume())
+	if err := startProcess(cmd); err != nil {
+		return ap.recordError(err)
+	}
+	if err := waitProcess(cmd); err != nil {
+		return ap.recordError(err)
+	}
+	return nil
+}
+
+func (ap *AudioPlayer) recordError(err error) error {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	return ap.recordErrorLocked(err)
+}
+
+// recordErrorLocked stores err for diagnostics; ap.mu must be held
+func (ap *AudioPlayer) recordErrorLocked(err error) error {
+	ap.lastError = err
+	ap.lastErrorAt = time.Now()
+	ap.pendingError = err
+	return err
+}
@@ -0,0 +1,103 @@
+package audio
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// fadeSteps is how many volume steps the afplay fallback uses to fade out
+const fadeSteps = 6
+
+// PlayWithFadeOut loops filePath at the current volume for hold, fades it
+// out over fade and then stops. Any later PlayMP3 or Stop cancels it.
+func (ap *AudioPlayer) PlayWithFadeOut(filePath string, hold, fade time.Duration) error {
+	if runtime.GOOS == "darwin" {
+		// afplay can neither loop nor fade, so step the volume down by
+		// restarting it; coarse, but good enough for falling asleep
+		return ap.playSteppedFade(filePath, hold, fade)
+	}
+
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+
+	// Generated tones are for focus, not for falling asleep
+	ap.stopLocked()
+	ap.stopToneLocked()
+
+	total := hold + fade
+	volumeInt := int(ap.volume * 100)
+
+	// ffplay: loop inside the filter graph so timestamps keep growing and
+	// afade sees one continuous stream
+	graph := fmt.Sprintf("amovie=%s:loop=0,afade=t=out:st=%.1f:d=%.1f,atrim=end=%.1f",
+		escapeFilterValue(filePath), hold.Seconds(), fade.Seconds(), total.Seconds())
+	cmd := exec.Command("ffplay", "-nodisp", "-autoexit", "-loglevel", "error",
+		"-volume", fmt.Sprintf("%d", volumeInt), "-f", "lavfi", graph)
+
+	if err := startProcess(cmd); err != nil {
+		// sox can do the same with its fade effect
+		cmd = exec.Command("play", "-q", "-v", fmt.Sprintf("%.2f", ap.volume), filePath,
+			"repeat", "-", "fade", "t", "0", fmt.Sprintf("%.1f", total.Seconds()), fmt.Sprintf("%.1f", fade.Seconds()))
+		if err := startProcess(cmd); err != nil {
+			return ap.recordErrorLocked(fmt.Errorf("failed to play MP3: no suitable audio player found"))
+		}
+	}
+
+	ap.trackCurrentLocked(cmd, filePath)
+	return nil
+}
+
+// playSteppedFade plays filePath at full volume for hold, then restarts it
+// at decreasing volumes until fade has elapsed
+func (ap *AudioPlayer) playSteppedFade(filePath string, hold, fade time.Duration) error {
+	ap.mu.Lock()
+	ap.stopLocked()
+	ap.stopToneLocked()
+	volume := ap.volume
+	gen := ap.fadeGen
+	ap.mu.Unlock()
+
+	step := fade / fadeSteps
+	play := func(v float64, d time.Duration) bool {
+		ap.mu.Lock()
+		defer ap.mu.Unlock()
+		if ap.fadeGen != gen {
+			return false // Cancelled by another PlayMP3 or Stop
+		}
+		ap.stopLocked()
+		ap.fadeGen = gen // Restarting our own step is not a cancellation
+		cmd := exec.Command("afplay", "-v", fmt.Sprintf("%.2f", v), "-t", fmt.Sprintf("%.1f", d.Seconds()), filePath)
+		if err := startProcess(cmd); err != nil {
+			ap.recordErrorLocked(fmt.Errorf("failed to play MP3 with afplay: %v", err))
+			return false
+		}
+		ap.trackCurrentLocked(cmd, filePath)
+		return true
+	}
+
+	if !play(volume, hold) {
+		return ap.LastError()
+	}
+
+	go func() {
+		time.Sleep(hold)
+		for i := 1; i <= fadeSteps; i++ {
+			v := volume * float64(fadeSteps-i) / fadeSteps
+			if !play(v, step) {
+				return
+			}
+			time.Sleep(step)
+		}
+	}()
+	return nil
+}
+
+// escapeFilterValue escapes a value for use inside an ffmpeg filter graph:
+// once for the option parser and once for the graph parser
+func escapeFilterValue(value string) string {
+	optionLevel := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`).Replace(value)
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`).Replace(optionLevel)
+}
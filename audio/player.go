@@ -3,13 +3,13 @@ package audio
 import (
 	"embed"
 	"fmt"
-	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 )
 
 type AudioPlayer struct {
@@ -20,8 +20,14 @@ type AudioPlayer struct {
 	isPaused           bool
 	loopEnabled        bool
 	currentCmd         *exec.Cmd
-	embeddedTempFile   string // Path to embedded whitenoise temp file
+	embeddedFile       string // Path to the cached embedded whitenoise
 	volume             float64 // Volume level (0.0 to 1.0)
+	lastError          error // Most recent playback failure (kept for diagnostics)
+	lastErrorAt        time.Time
+	pendingError       error // Failure not yet reported to the UI
+	fadeGen            int   // Bumped to cancel a stepped fade in progress
+	tone               *ToneConfig // Generated tone layered over the whitenoise
+	toneCmd            *exec.Cmd
 	mu                 sync.Mutex
 }
 
@@ -40,7 +46,9 @@ func NewAudioPlayer(whitenoiseDir string) (*AudioPlayer, error) {
 	return ap, nil
 }
 
-func NewAudioPlayerWithEmbed(whitenoiseDir string, assetsFS embed.FS) (*AudioPlayer, error) {
+// NewAudioPlayerWithEmbed creates a player whose library starts with the
+// embedded whitenoise, cached under cacheDir, followed by user files
+func NewAudioPlayerWithEmbed(whitenoiseDir, cacheDir string, assetsFS embed.FS) (*AudioPlayer, error) {
 	ap := &AudioPlayer{
 		whitenoiseDir: whitenoiseDir,
 		loopEnabled:   true,
@@ -48,7 +56,7 @@ func NewAudioPlayerWithEmbed(whitenoiseDir string, assetsFS embed.FS) (*AudioPla
 	}
 
 	// Load embedded rain-and-thunder.mp3
-	if err := ap.loadEmbeddedWhitenoise(assetsFS); err != nil {
+	if err := ap.loadEmbeddedWhitenoise(cacheDir, assetsFS); err != nil {
 		// Not fatal - embedded whitenoise is optional
 		fmt.Fprintf(os.Stderr, "Warning: Failed to load embedded whitenoise: %v\n", err)
 	}
@@ -65,34 +73,19 @@ func NewAudioPlayerWithEmbed(whitenoiseDir string, assetsFS embed.FS) (*AudioPla
 	return ap, nil
 }
 
-func (ap *AudioPlayer) loadEmbeddedWhitenoise(assetsFS embed.FS) error {
-	// Try to read rain-and-thunder.mp3 from embedded assets
-	data, err := fs.ReadFile(assetsFS, "whitenoise/rain-and-thunder.mp3")
+func (ap *AudioPlayer) loadEmbeddedWhitenoise(cacheDir string, assetsFS embed.FS) error {
+	// Reuse the cached copy of rain-and-thunder.mp3, writing it on first run
+	cachedPath, err := CacheAsset(cacheDir, assetsFS, "whitenoise/rain-and-thunder.mp3")
 	if err != nil {
-		return fmt.Errorf("failed to read embedded rain-and-thunder.mp3: %w", err)
+		return err
 	}
 
-	// Create temporary file for embedded whitenoise
-	tmpFile, err := os.CreateTemp("", "zoneout-whitenoise-*.mp3")
-	if err != nil {
-		return fmt.Errorf("failed to create temp file for whitenoise: %w", err)
-	}
-
-	// Write embedded MP3 data to temp file
-	if _, err := tmpFile.Write(data); err != nil {
-		tmpFile.Close()
-		os.Remove(tmpFile.Name())
-		return fmt.Errorf("failed to write whitenoise to temp file: %w", err)
-	}
-
-	tmpFile.Close()
-
 	// Add to available MP3s
 	ap.mu.Lock()
 	defer ap.mu.Unlock()
 
-	ap.availableMP3s = append(ap.availableMP3s, tmpFile.Name())
-	ap.embeddedTempFile = tmpFile.Name()
+	ap.availableMP3s = append(ap.availableMP3s, cachedPath)
+	ap.embeddedFile = cachedPath
 
 	return nil
 }
@@ -103,8 +96,8 @@ func (ap *AudioPlayer) ScanWhitenoiseDirectory() error {
 
 	// Preserve embedded MP3 if it exists
 	var embeddedMP3 string
-	if ap.embeddedTempFile != "" {
-		embeddedMP3 = ap.embeddedTempFile
+	if ap.embeddedFile != "" {
+		embeddedMP3 = ap.embeddedFile
 	}
 
 	// Clear user-provided MP3s (but keep embedded)
@@ -148,10 +141,7 @@ func (ap *AudioPlayer) PlayMP3(filePath string) error {
 	defer ap.mu.Unlock()
 
 	// Stop current playback if any
-	if ap.currentCmd != nil && ap.currentCmd.Process != nil {
-		ap.currentCmd.Process.Kill()
-		ap.currentCmd = nil
-	}
+	ap.stopLocked()
 
 	// Use appropriate audio player based on OS
 	volumeStr := fmt.Sprintf("%.2f", ap.volume)
@@ -169,33 +159,44 @@ func (ap *AudioPlayer) PlayMP3(filePath string) error {
 		cmd = exec.Command("ffplay", "-nodisp", "-autoexit", "-loop", "0", "-volume", fmt.Sprintf("%d", volumeInt), filePath)
 	}
 
-	if err := cmd.Start(); err != nil {
+	if err := startProcess(cmd); err != nil {
 		// Try alternative player if first one fails
 		if runtime.GOOS != "darwin" {
 			cmd = exec.Command("play", "-q", filePath)
-			if err := cmd.Start(); err != nil {
-				return fmt.Errorf("failed to play MP3: no suitable audio player found")
+			if err := startProcess(cmd); err != nil {
+				return ap.recordErrorLocked(fmt.Errorf("failed to play MP3: no suitable audio player found"))
 			}
 		} else {
-			return fmt.Errorf("failed to play MP3 with afplay: %v", err)
+			return ap.recordErrorLocked(fmt.Errorf("failed to play MP3 with afplay: %v", err))
 		}
 	}
 
+	ap.trackCurrentLocked(cmd, filePath)
+	return nil
+}
+
+// trackCurrentLocked makes cmd the current player and watches it for
+// failures; ap.mu must be held
+func (ap *AudioPlayer) trackCurrentLocked(cmd *exec.Cmd, filePath string) {
 	ap.currentCmd = cmd
 	ap.currentMP3 = filePath
 	ap.isPlaying = true
+	ap.isPaused = false
 
 	// Run the command in a background goroutine to monitor it
 	go func() {
-		ap.currentCmd.Wait()
+		err := waitProcess(cmd)
 		ap.mu.Lock()
 		defer ap.mu.Unlock()
-		if ap.currentCmd != nil && ap.isPlaying {
+		// Only the command we started is of interest; a killed or replaced
+		// player is an intentional stop, not a failure.
+		if ap.currentCmd == cmd && ap.isPlaying {
 			ap.isPlaying = false
+			if err != nil {
+				ap.recordErrorLocked(fmt.Errorf("%s exited: %v", filepath.Base(cmd.Path), err))
+			}
 		}
 	}()
-
-	return nil
 }
 
 func (ap *AudioPlayer) SwitchMP3(filePath string) error {
@@ -211,6 +212,7 @@ func (ap *AudioPlayer) Pause() {
 		ap.isPaused = true
 		ap.isPlaying = false
 	}
+	ap.stopToneLocked()
 }
 
 func (ap *AudioPlayer) Resume() {
@@ -222,6 +224,7 @@ func (ap *AudioPlayer) Resume() {
 	// For system audio players, we'll need to restart the file
 	if isPaused && currentMP3 != "" {
 		ap.PlayMP3(currentMP3)
+		ap.PlayTone()
 	}
 }
 
@@ -229,22 +232,18 @@ func (ap *AudioPlayer) Stop() {
 	ap.mu.Lock()
 	defer ap.mu.Unlock()
 
-	if ap.currentCmd != nil && ap.currentCmd.Process != nil {
-		ap.currentCmd.Process.Kill()
-		ap.currentCmd = nil
-	}
+	ap.stopLocked()
+	ap.stopToneLocked()
 	ap.isPlaying = false
 	ap.isPaused = false
 }
 
-func (ap *AudioPlayer) Cleanup() {
-	ap.mu.Lock()
-	defer ap.mu.Unlock()
-
-	// Remove temporary embedded whitenoise file
-	if ap.embeddedTempFile != "" {
-		os.Remove(ap.embeddedTempFile)
-		ap.embeddedTempFile = ""
+// stopLocked kills the current player and cancels any fade; ap.mu must be held
+func (ap *AudioPlayer) stopLocked() {
+	ap.fadeGen++
+	if ap.currentCmd != nil && ap.currentCmd.Process != nil {
+		killProcess(ap.currentCmd)
+		ap.currentCmd = nil
 	}
 }
 
@@ -276,31 +275,33 @@ func (ap *AudioPlayer) PlaySoundEffect(filePath string) {
 
 	// Play sound effect in a background goroutine to avoid blocking
 	go func() {
-		volumeStr := fmt.Sprintf("%.2f", volume)
-		volumeInt := int(volume * 100)
-
-		var cmd *exec.Cmd
-		if runtime.GOOS == "darwin" {
-			// macOS - use afplay with volume
-			cmd = exec.Command("afplay", "-v", volumeStr, filePath)
-		} else if runtime.GOOS == "linux" {
-			// Linux - use ffplay with volume
-			cmd = exec.Command("ffplay", "-nodisp", "-autoexit", "-volume", fmt.Sprintf("%d", volumeInt), filePath)
-		} else {
-			// Windows - use ffplay with volume
-			cmd = exec.Command("ffplay", "-nodisp", "-autoexit", "-volume", fmt.Sprintf("%d", volumeInt), filePath)
-		}
+		cmd := soundEffectCommand(filePath, volume)
 
-		if err := cmd.Start(); err != nil {
-			// Silently fail if sound effect can't be played
+		if err := startProcess(cmd); err != nil {
+			ap.recordError(fmt.Errorf("failed to play sound effect: %v", err))
 			return
 		}
 
 		// Wait for the sound to finish playing
-		cmd.Wait()
+		if err := waitProcess(cmd); err != nil {
+			ap.recordError(fmt.Errorf("sound effect %s exited: %v", filepath.Base(filePath), err))
+		}
 	}()
 }
 
+// soundEffectCommand builds the one-shot player command for a sound effect
+func soundEffectCommand(filePath string, volume float64) *exec.Cmd {
+	volumeStr := fmt.Sprintf("%.2f", volume)
+	volumeInt := int(volume * 100)
+
+	if runtime.GOOS == "darwin" {
+		// macOS - use afplay with volume
+		return exec.Command("afplay", "-v", volumeStr, filePath)
+	}
+	// Linux, Windows and others - use ffplay with volume
+	return exec.Command("ffplay", "-nodisp", "-autoexit", "-volume", fmt.Sprintf("%d", volumeInt), filePath)
+}
+
 func (ap *AudioPlayer) Close() error {
 	ap.Stop()
 	return nil
@@ -0,0 +1,89 @@
+package audio
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Every player process we spawn is tracked so it can be killed when zoneout
+// exits, including on signals where deferred cleanup would not run
+var (
+	childrenMu sync.Mutex
+	children   = map[*exec.Cmd]struct{}{}
+)
+
+// startProcess starts cmd in its own process group and tracks it
+func startProcess(cmd *exec.Cmd) error {
+	configureProcess(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	childrenMu.Lock()
+	children[cmd] = struct{}{}
+	childrenMu.Unlock()
+	return nil
+}
+
+// waitProcess waits for cmd to exit and stops tracking it
+func waitProcess(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+
+	childrenMu.Lock()
+	delete(children, cmd)
+	childrenMu.Unlock()
+	return err
+}
+
+// killProcess kills cmd together with anything it spawned
+func killProcess(cmd *exec.Cmd) {
+	if cmd == nil || cmd.Process == nil {
+		return
+	}
+	killProcessGroup(cmd)
+}
+
+// KillAll kills every player process started by this package. It is safe to
+// call from a signal handler goroutine and more than once.
+func KillAll() {
+	childrenMu.Lock()
+	defer childrenMu.Unlock()
+
+	for cmd := range children {
+		killProcessGroup(cmd)
+	}
+}
+
+// tempFilePrefix is shared by the temp files older versions of zoneout
+// extracted embedded assets to
+const tempFilePrefix = "zoneout-"
+
+// staleTempFileAge is how old such a temp file must be before it is
+// removed, as a running older build may still be playing it
+const staleTempFileAge = 24 * time.Hour
+
+// RemoveStaleTempFiles deletes temp files left by older versions that
+// crashed or were killed. Assets now live in the cache directory, but a
+// running older build may still own some, so files are kept until they are
+// a day old.
+func RemoveStaleTempFiles() int {
+	matches, err := filepath.Glob(filepath.Join(os.TempDir(), tempFilePrefix+"*.mp3"))
+	if err != nil {
+		return 0
+	}
+
+	removed := 0
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || time.Since(info.ModTime()) < staleTempFileAge {
+			continue
+		}
+		if os.Remove(path) == nil {
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,22 @@
+package audio
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// configureProcess places the player in its own process group and asks the
+// kernel to kill it if zoneout dies, even on SIGKILL or a crash
+func configureProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid:   true,
+		Pdeathsig: syscall.SIGKILL,
+	}
+}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	// A negative PID signals the whole group
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package audio
+
+import "os/exec"
+
+func configureProcess(cmd *exec.Cmd) {}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
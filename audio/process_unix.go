@@ -0,0 +1,21 @@
+//go:build unix && !linux
+
+package audio
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// configureProcess places the player in its own process group so it and
+// any helpers it spawns can be killed together
+func configureProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	// A negative PID signals the whole group
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		cmd.Process.Kill()
+	}
+}
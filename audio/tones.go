@@ -0,0 +1,224 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os/exec"
+)
+
+// ToneKind selects how a generated tone encodes its beat frequency
+type ToneKind int
+
+const (
+	// ToneBinaural plays a slightly different frequency in each ear; the
+	// beat is perceived from the difference (headphones required)
+	ToneBinaural ToneKind = iota
+	// ToneIsochronic pulses a single tone on and off at the beat frequency
+	ToneIsochronic
+)
+
+const (
+	// ToneSampleRate is the sample rate of generated tones in Hz
+	ToneSampleRate = 44100
+	// toneAmplitude keeps generated tones well below full scale, they are
+	// meant to sit underneath the whitenoise
+	toneAmplitude = 0.3
+	// isochronicDuty is the fraction of each beat period the tone is on
+	isochronicDuty = 0.5
+	// isochronicRamp is the fraction of each beat period used to fade the
+	// pulse in and out, avoiding clicks
+	isochronicRamp = 0.1
+)
+
+// ToneConfig describes a generated focus tone
+type ToneConfig struct {
+	Name    string
+	Kind    ToneKind
+	Carrier float64 // Base frequency in Hz
+	Beat    float64 // Beat frequency in Hz, e.g. 40 for gamma
+}
+
+// DefaultTonePresets are the tones offered in the audio menu
+var DefaultTonePresets = []ToneConfig{
+	{Name: "Binaural 40Hz gamma", Kind: ToneBinaural, Carrier: 200, Beat: 40},
+	{Name: "Binaural 18Hz beta", Kind: ToneBinaural, Carrier: 200, Beat: 18},
+	{Name: "Binaural 10Hz alpha", Kind: ToneBinaural, Carrier: 200, Beat: 10},
+	{Name: "Isochronic 40Hz gamma", Kind: ToneIsochronic, Carrier: 300, Beat: 40},
+	{Name: "Isochronic 10Hz alpha", Kind: ToneIsochronic, Carrier: 300, Beat: 10},
+}
+
+// LeftFrequency is the frequency played in the left channel
+func (c ToneConfig) LeftFrequency() float64 {
+	return c.Carrier
+}
+
+// RightFrequency is the frequency played in the right channel
+func (c ToneConfig) RightFrequency() float64 {
+	if c.Kind == ToneBinaural {
+		return c.Carrier + c.Beat
+	}
+	return c.Carrier
+}
+
+// ToneGenerator produces an endless stream of 16-bit little-endian stereo
+// PCM at ToneSampleRate for a ToneConfig
+type ToneGenerator struct {
+	config  ToneConfig
+	sample  int64 // Index of the next frame
+	frame   [4]byte
+	pending []byte // Bytes of the current frame not yet read
+}
+
+func NewToneGenerator(config ToneConfig) *ToneGenerator {
+	return &ToneGenerator{config: config}
+}
+
+// Next returns the next stereo frame with samples in [-1, 1]
+func (g *ToneGenerator) Next() (left, right float64) {
+	n := g.sample
+	g.sample++
+
+	switch g.config.Kind {
+	case ToneIsochronic:
+		envelope := isochronicEnvelope(cyclePosition(g.config.Beat, n))
+		s := math.Sin(2*math.Pi*cyclePosition(g.config.Carrier, n)) * envelope
+		left, right = s, s
+	default:
+		left = math.Sin(2 * math.Pi * cyclePosition(g.config.LeftFrequency(), n))
+		right = math.Sin(2 * math.Pi * cyclePosition(g.config.RightFrequency(), n))
+	}
+	return left * toneAmplitude, right * toneAmplitude
+}
+
+// Read fills p with PCM frames; it never returns an error
+func (g *ToneGenerator) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(g.pending) == 0 {
+			left, right := g.Next()
+			binary.LittleEndian.PutUint16(g.frame[0:], uint16(toPCM16(left)))
+			binary.LittleEndian.PutUint16(g.frame[2:], uint16(toPCM16(right)))
+			g.pending = g.frame[:]
+		}
+		copied := copy(p[n:], g.pending)
+		g.pending = g.pending[copied:]
+		n += copied
+	}
+	return n, nil
+}
+
+// cyclePosition returns how far into its current cycle a wave of frequency
+// f is at frame n, in [0, 1). Working from the frame index rather than an
+// accumulated phase keeps long sessions free of drift.
+func cyclePosition(f float64, n int64) float64 {
+	pos := math.Mod(f*float64(n), ToneSampleRate) / ToneSampleRate
+	if pos < 0 {
+		pos++
+	}
+	return pos
+}
+
+// isochronicEnvelope is the pulse amplitude at pos within a beat period:
+// on for the first isochronicDuty with raised-cosine edges, then silent
+func isochronicEnvelope(pos float64) float64 {
+	switch {
+	case pos < isochronicRamp:
+		return 0.5 - 0.5*math.Cos(math.Pi*pos/isochronicRamp)
+	case pos < isochronicDuty-isochronicRamp:
+		return 1
+	case pos < isochronicDuty:
+		return 0.5 + 0.5*math.Cos(math.Pi*(pos-(isochronicDuty-isochronicRamp))/isochronicRamp)
+	default:
+		return 0
+	}
+}
+
+func toPCM16(s float64) int16 {
+	if s > 1 {
+		s = 1
+	} else if s < -1 {
+		s = -1
+	}
+	return int16(math.Round(s * math.MaxInt16))
+}
+
+// SelectTone sets the tone layered over the whitenoise, nil turns it off.
+// If ambient audio is playing the tone starts right away.
+func (ap *AudioPlayer) SelectTone(tone *ToneConfig) error {
+	ap.mu.Lock()
+	ap.stopToneLocked()
+	ap.tone = tone
+	start := tone != nil && ap.isPlaying
+	ap.mu.Unlock()
+
+	if start {
+		return ap.PlayTone()
+	}
+	return nil
+}
+
+// SelectedTone returns the selected tone, or nil
+func (ap *AudioPlayer) SelectedTone() *ToneConfig {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	return ap.tone
+}
+
+// PlayTone starts the selected tone in its own player process so the system
+// mixer blends it with the whitenoise
+func (ap *AudioPlayer) PlayTone() error {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+
+	if ap.tone == nil {
+		return nil
+	}
+	ap.stopToneLocked()
+
+	// Raw PCM is piped to stdin; afplay can't read a stream, so sox is the
+	// fallback everywhere
+	volumeInt := int(ap.volume * 100)
+	rate := fmt.Sprintf("%d", ToneSampleRate)
+	cmd := exec.Command("ffplay", "-nodisp", "-loglevel", "error", "-volume", fmt.Sprintf("%d", volumeInt),
+		"-f", "s16le", "-ar", rate, "-ac", "2", "-i", "-")
+	cmd.Stdin = NewToneGenerator(*ap.tone)
+	if err := startProcess(cmd); err != nil {
+		cmd = exec.Command("play", "-q", "-v", fmt.Sprintf("%.2f", ap.volume),
+			"-t", "raw", "-r", rate, "-e", "signed", "-b", "16", "-c", "2", "-")
+		cmd.Stdin = NewToneGenerator(*ap.tone)
+		if err := startProcess(cmd); err != nil {
+			return ap.recordErrorLocked(fmt.Errorf("failed to play tone: no suitable audio player found"))
+		}
+	}
+	ap.toneCmd = cmd
+
+	go func() {
+		err := waitProcess(cmd)
+		ap.mu.Lock()
+		defer ap.mu.Unlock()
+		if ap.toneCmd == cmd {
+			ap.toneCmd = nil
+			if err != nil {
+				ap.recordErrorLocked(fmt.Errorf("tone player exited: %v", err))
+			}
+		}
+	}()
+	return nil
+}
+
+// IsTonePlaying reports whether a tone player is running
+func (ap *AudioPlayer) IsTonePlaying() bool {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	return ap.toneCmd != nil
+}
+
+// stopToneLocked kills the tone player, keeping the selection; ap.mu must be held
+func (ap *AudioPlayer) stopToneLocked() {
+	if ap.toneCmd != nil {
+		cmd := ap.toneCmd
+		ap.toneCmd = nil
+		killProcess(cmd)
+	}
+}
@@ -0,0 +1,148 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+)
+
+const testSeconds = 3
+
+// readTone reads testSeconds of PCM from a generator for config, in odd
+// sized chunks so frames get split across reads
+func readTone(t *testing.T, config ToneConfig) (left, right []float64) {
+	t.Helper()
+	pcm := make([]byte, testSeconds*ToneSampleRate*4)
+	r := io.LimitReader(NewToneGenerator(config), int64(len(pcm)))
+	buf := make([]byte, 1001)
+	n := 0
+	for n < len(pcm) {
+		m, err := r.Read(buf)
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		n += copy(pcm[n:], buf[:m])
+	}
+
+	for i := 0; i < len(pcm); i += 4 {
+		l := int16(binary.LittleEndian.Uint16(pcm[i:]))
+		r := int16(binary.LittleEndian.Uint16(pcm[i+2:]))
+		left = append(left, float64(l)/math.MaxInt16)
+		right = append(right, float64(r)/math.MaxInt16)
+	}
+	return left, right
+}
+
+// crossingFrequency estimates the frequency of a pure tone from its zero
+// crossings
+func crossingFrequency(samples []float64) float64 {
+	crossings := 0
+	for i := 1; i < len(samples); i++ {
+		if (samples[i-1] < 0) != (samples[i] < 0) {
+			crossings++
+		}
+	}
+	return float64(crossings) / 2 / testSeconds
+}
+
+// goertzel returns the amplitude of frequency f in samples
+func goertzel(samples []float64, f float64) float64 {
+	coeff := 2 * math.Cos(2*math.Pi*f/ToneSampleRate)
+	var s1, s2 float64
+	for _, x := range samples {
+		s1, s2 = x+coeff*s1-s2, s1
+	}
+	power := s1*s1 + s2*s2 - coeff*s1*s2
+	return 2 * math.Sqrt(power) / float64(len(samples))
+}
+
+// pulses counts the isochronic pulses: runs of sound after a silence long
+// enough not to be a zero crossing of the carrier
+func pulses(samples []float64) int {
+	const minSilence = 20
+	count, silent := 0, minSilence
+	for _, s := range samples {
+		if s == 0 {
+			silent++
+			continue
+		}
+		if silent >= minSilence {
+			count++
+		}
+		silent = 0
+	}
+	return count
+}
+
+func TestBinauralFrequencies(t *testing.T) {
+	for _, preset := range DefaultTonePresets {
+		if preset.Kind != ToneBinaural {
+			continue
+		}
+		t.Run(preset.Name, func(t *testing.T) {
+			left, right := readTone(t, preset)
+
+			if got, want := preset.RightFrequency()-preset.LeftFrequency(), preset.Beat; got != want {
+				t.Errorf("beat = %v Hz, want %v Hz", got, want)
+			}
+			if got := crossingFrequency(left); math.Abs(got-preset.LeftFrequency()) > 1 {
+				t.Errorf("left = %.1f Hz, want %v Hz", got, preset.LeftFrequency())
+			}
+			if got := crossingFrequency(right); math.Abs(got-preset.RightFrequency()) > 1 {
+				t.Errorf("right = %.1f Hz, want %v Hz", got, preset.RightFrequency())
+			}
+
+			// Each ear hears only its own carrier
+			if a := goertzel(left, preset.LeftFrequency()); math.Abs(a-toneAmplitude) > 0.01 {
+				t.Errorf("left amplitude at %v Hz = %.3f, want %v", preset.LeftFrequency(), a, toneAmplitude)
+			}
+			if a := goertzel(left, preset.RightFrequency()); a > 0.01 {
+				t.Errorf("left amplitude at %v Hz = %.3f, want 0", preset.RightFrequency(), a)
+			}
+			if a := goertzel(right, preset.RightFrequency()); math.Abs(a-toneAmplitude) > 0.01 {
+				t.Errorf("right amplitude at %v Hz = %.3f, want %v", preset.RightFrequency(), a, toneAmplitude)
+			}
+			if a := goertzel(right, preset.LeftFrequency()); a > 0.01 {
+				t.Errorf("right amplitude at %v Hz = %.3f, want 0", preset.LeftFrequency(), a)
+			}
+		})
+	}
+}
+
+func TestIsochronicPulses(t *testing.T) {
+	for _, preset := range DefaultTonePresets {
+		if preset.Kind != ToneIsochronic {
+			continue
+		}
+		t.Run(preset.Name, func(t *testing.T) {
+			left, right := readTone(t, preset)
+
+			if preset.LeftFrequency() != preset.Carrier || preset.RightFrequency() != preset.Carrier {
+				t.Errorf("channels = %v/%v Hz, want %v Hz in both", preset.LeftFrequency(), preset.RightFrequency(), preset.Carrier)
+			}
+			for i := range left {
+				if left[i] != right[i] {
+					t.Fatalf("frame %d: left %v != right %v", i, left[i], right[i])
+				}
+			}
+
+			if got, want := pulses(left), int(preset.Beat*testSeconds); got != want {
+				t.Errorf("%d pulses in %ds, want %d", got, testSeconds, want)
+			}
+			// The carrier dominates; the pulses only shape its envelope
+			if a, b := goertzel(left, preset.Carrier), goertzel(left, preset.Carrier*1.5); a < 10*b {
+				t.Errorf("amplitude at carrier %v Hz = %.3f, not above %.3f elsewhere", preset.Carrier, a, b)
+			}
+		})
+	}
+}
+
+func TestTonePCMRange(t *testing.T) {
+	left, right := readTone(t, DefaultTonePresets[0])
+	for i := range left {
+		if math.Abs(left[i]) > toneAmplitude+0.001 || math.Abs(right[i]) > toneAmplitude+0.001 {
+			t.Fatalf("frame %d = %v/%v, above amplitude %v", i, left[i], right[i], toneAmplitude)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package calendar reads meetings from a local iCalendar (.ics) file, such
+// as an exported work calendar, so focus sessions can end before them.
+package calendar
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Event is one occurrence of a meeting
+type Event struct {
+	Summary string
+	Start   time.Time
+	End     time.Time
+}
+
+// Calendar is an .ics file, read again when it changes
+type Calendar struct {
+	path string
+
+	mu          sync.Mutex
+	events      []vevent
+	modTime     time.Time
+	size        int64
+	checked     time.Time // Last look at the file
+	day         time.Time // Occurrences are expanded around this day
+	occurrences []Event
+	err         error
+	lastErr     string // Not reported again until it changes
+}
+
+// checkInterval is how often the file is checked for changes
+const checkInterval = 5 * time.Second
+
+// New returns the calendar in the .ics file at path
+func New(path string) *Calendar {
+	return &Calendar{path: path}
+}
+
+// Path returns the .ics file
+func (c *Calendar) Path() string {
+	return c.path
+}
+
+// TakeError returns and clears the last error reading the file, or the
+// events in it that had to be skipped
+func (c *Calendar) TakeError() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.err
+	c.err = nil
+	return err
+}
+
+// Next returns the first meeting starting after now, up to a day ahead
+func (c *Calendar) Next(now time.Time) (Event, bool) {
+	for _, ev := range c.around(now) {
+		if ev.Start.After(now) {
+			return ev, true
+		}
+	}
+	return Event{}, false
+}
+
+// Current returns the meeting in progress at now
+func (c *Calendar) Current(now time.Time) (Event, bool) {
+	for _, ev := range c.around(now) {
+		if !ev.Start.After(now) && ev.End.After(now) {
+			return ev, true
+		}
+	}
+	return Event{}, false
+}
+
+// Between returns the meetings overlapping [from, to), by start time
+func (c *Calendar) Between(from, to time.Time) ([]Event, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reload(time.Now())
+	if c.events == nil && c.lastErr != "" {
+		return nil, fmt.Errorf("%s", c.lastErr)
+	}
+	return expand(c.events, from, to), nil
+}
+
+// around returns the meetings from yesterday until the day after tomorrow
+func (c *Calendar) around(now time.Time) []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reloaded := c.reload(now)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if reloaded || !day.Equal(c.day) {
+		c.day = day
+		c.occurrences = expand(c.events, day.AddDate(0, 0, -1), day.AddDate(0, 0, 2))
+	}
+	return c.occurrences
+}
+
+// reload reads the file if it changed, reporting whether it did. c.mu must
+// be held.
+func (c *Calendar) reload(now time.Time) bool {
+	if now.Sub(c.checked) < checkInterval && !c.checked.IsZero() {
+		return false
+	}
+	c.checked = now
+
+	info, err := os.Stat(c.path)
+	if err != nil {
+		c.fail(fmt.Errorf("calendar: %w", err))
+		return false
+	}
+	if info.ModTime().Equal(c.modTime) && info.Size() == c.size && c.events != nil {
+		return false
+	}
+
+	f, err := os.Open(c.path)
+	if err != nil {
+		c.fail(fmt.Errorf("calendar: %w", err))
+		return false
+	}
+	defer f.Close()
+	events, skipped, err := parse(f)
+	if err != nil {
+		c.fail(fmt.Errorf("calendar %s: %w", c.path, err))
+		return false
+	}
+	c.events = append([]vevent{}, events...) // Not nil once read
+	c.modTime = info.ModTime()
+	c.size = info.Size()
+	switch {
+	case len(skipped) == 1:
+		c.fail(fmt.Errorf("calendar %s: skipped %w", c.path, skipped[0]))
+	case len(skipped) > 1:
+		c.fail(fmt.Errorf("calendar %s: skipped %d events, the first %w", c.path, len(skipped), skipped[0]))
+	default:
+		c.lastErr = ""
+	}
+	return true
+}
+
+// fail reports err unless it was the last one reported; c.mu must be held
+func (c *Calendar) fail(err error) {
+	if err.Error() != c.lastErr {
+		c.lastErr = err.Error()
+		c.err = err
+	}
+}
+
+// expand returns the occurrences of events overlapping [from, to)
+func expand(events []vevent, from, to time.Time) []Event {
+	// Occurrences of recurring events moved or edited on their own
+	moved := make(map[string]bool)
+	for _, ev := range events {
+		if !ev.recurrenceID.IsZero() {
+			moved[fmt.Sprintf("%s/%d", ev.uid, ev.recurrenceID.Unix())] = true
+		}
+	}
+
+	var result []Event
+	add := func(ev vevent, start time.Time) {
+		end := start.Add(ev.duration)
+		if start.Before(to) && (end.After(from) || !start.Before(from)) {
+			result = append(result, Event{Summary: ev.summary, Start: start, End: end})
+		}
+	}
+	for _, ev := range events {
+		if ev.rule == nil || !ev.recurrenceID.IsZero() {
+			add(ev, ev.start)
+			continue
+		}
+		ev.rule.each(ev.start, func(start time.Time) bool {
+			if !start.Before(to) {
+				return false
+			}
+			if !ev.exdates[start.Unix()] && !moved[fmt.Sprintf("%s/%d", ev.uid, start.Unix())] {
+				add(ev, start)
+			}
+			return true
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+	return result
+}
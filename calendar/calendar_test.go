@@ -0,0 +1,257 @@
+package calendar
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // Europe/Berlin without the system's zoneinfo
+)
+
+// event wraps content lines in a VEVENT
+func event(lines ...string) string {
+	return "BEGIN:VEVENT\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VEVENT\r\n"
+}
+
+func ics(events ...string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" + strings.Join(events, "") + "END:VCALENDAR\r\n"
+}
+
+func TestExpand(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, berlin)
+	to := time.Date(2027, 1, 1, 0, 0, 0, 0, berlin)
+
+	tests := []struct {
+		name     string
+		events   []string
+		from, to time.Time // Default from and to
+		want     []string  // Start times in Berlin, and summaries
+		skipped  int
+	}{
+		{
+			name:   "single",
+			events: []string{event("UID:1", "SUMMARY:Kickoff\\, team", "DTSTART:20261005T080000Z", "DURATION:PT1H")},
+			want:   []string{"2026-10-05 10:00 CEST Kickoff, team"},
+		},
+		{
+			name: "all-day, cancelled and free events",
+			events: []string{
+				event("UID:1", "SUMMARY:Holiday", "DTSTART;VALUE=DATE:20261005"),
+				event("UID:2", "SUMMARY:Off", "STATUS:CANCELLED", "DTSTART:20261005T080000Z"),
+				event("UID:3", "SUMMARY:Focus", "TRANSP:TRANSPARENT", "DTSTART:20261005T080000Z"),
+			},
+		},
+		{
+			name: "weekly BYDAY",
+			events: []string{event("UID:1", "SUMMARY:Standup", "DTSTART;TZID=Europe/Berlin:20261005T093000",
+				"DTEND;TZID=Europe/Berlin:20261005T094500", "RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=5")},
+			want: []string{
+				"2026-10-05 09:30 CEST Standup",
+				"2026-10-07 09:30 CEST Standup",
+				"2026-10-09 09:30 CEST Standup",
+				"2026-10-12 09:30 CEST Standup",
+				"2026-10-14 09:30 CEST Standup",
+			},
+		},
+		{
+			// RFC 5545's example, WKST changes which weeks are skipped
+			name: "weekly INTERVAL and WKST",
+			events: []string{
+				event("UID:1", "SUMMARY:Mondays", "DTSTART;TZID=Europe/Berlin:19970805T090000",
+					"RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO"),
+				event("UID:2", "SUMMARY:Sundays", "DTSTART;TZID=Europe/Berlin:19970805T100000",
+					"RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU"),
+			},
+			from: time.Date(1997, 8, 1, 0, 0, 0, 0, berlin),
+			to:   time.Date(1997, 9, 1, 0, 0, 0, 0, berlin),
+			want: []string{
+				"1997-08-05 09:00 CEST Mondays",
+				"1997-08-05 10:00 CEST Sundays",
+				"1997-08-10 09:00 CEST Mondays",
+				"1997-08-17 10:00 CEST Sundays",
+				"1997-08-19 09:00 CEST Mondays",
+				"1997-08-19 10:00 CEST Sundays",
+				"1997-08-24 09:00 CEST Mondays",
+				"1997-08-31 10:00 CEST Sundays",
+			},
+		},
+		{
+			name: "COUNT",
+			events: []string{event("UID:1", "SUMMARY:1:1", "DTSTART;TZID=Europe/Berlin:20261006T150000",
+				"RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=3")},
+			want: []string{
+				"2026-10-06 15:00 CEST 1:1",
+				"2026-10-20 15:00 CEST 1:1",
+				"2026-11-03 15:00 CET 1:1",
+			},
+		},
+		{
+			name: "UNTIL",
+			events: []string{
+				event("UID:1", "SUMMARY:Sprint", "DTSTART;TZID=Europe/Berlin:20261005T100000",
+					"RRULE:FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;UNTIL=20261012T080000Z"),
+				event("UID:2", "SUMMARY:Retro", "DTSTART;TZID=Europe/Berlin:20261007T160000",
+					"RRULE:FREQ=DAILY;INTERVAL=3;UNTIL=20261013"),
+			},
+			want: []string{
+				"2026-10-05 10:00 CEST Sprint",
+				"2026-10-06 10:00 CEST Sprint",
+				"2026-10-07 10:00 CEST Sprint",
+				"2026-10-07 16:00 CEST Retro",
+				"2026-10-08 10:00 CEST Sprint",
+				"2026-10-09 10:00 CEST Sprint",
+				"2026-10-10 16:00 CEST Retro",
+				"2026-10-12 10:00 CEST Sprint", // UNTIL is inclusive
+				"2026-10-13 16:00 CEST Retro",
+			},
+		},
+		{
+			name: "EXDATE",
+			events: []string{event("UID:1", "SUMMARY:Sync", "DTSTART;TZID=Europe/Berlin:20261005T110000",
+				"RRULE:FREQ=DAILY;COUNT=5", "EXDATE;TZID=Europe/Berlin:20261006T110000,20261008T110000",
+				"EXDATE:20261009T090000Z")},
+			want: []string{
+				"2026-10-05 11:00 CEST Sync",
+				"2026-10-07 11:00 CEST Sync",
+			},
+		},
+		{
+			name: "moved occurrence",
+			events: []string{
+				event("UID:standup", "SUMMARY:Standup", "DTSTART;TZID=Europe/Berlin:20261005T093000",
+					"DURATION:PT15M", "RRULE:FREQ=WEEKLY;COUNT=3"),
+				event("UID:standup", "SUMMARY:Standup (moved)", "RECURRENCE-ID;TZID=Europe/Berlin:20261012T093000",
+					"DTSTART;TZID=Europe/Berlin:20261012T140000", "DURATION:PT15M"),
+			},
+			want: []string{
+				"2026-10-05 09:30 CEST Standup",
+				"2026-10-12 14:00 CEST Standup (moved)",
+				"2026-10-19 09:30 CEST Standup",
+			},
+		},
+		{
+			// Summer time ends on October 25th, the meeting stays at 09:30
+			name: "DST",
+			events: []string{event("UID:1", "SUMMARY:Planning", "DTSTART;TZID=Europe/Berlin:20261019T093000",
+				"RRULE:FREQ=WEEKLY;COUNT=2")},
+			want: []string{
+				"2026-10-19 09:30 CEST Planning",
+				"2026-10-26 09:30 CET Planning",
+			},
+		},
+		{
+			name: "monthly ordinal BYDAY",
+			events: []string{
+				event("UID:1", "SUMMARY:First Monday", "DTSTART;TZID=Europe/Berlin:20261005T100000",
+					"RRULE:FREQ=MONTHLY;BYDAY=1MO"),
+				event("UID:2", "SUMMARY:Last Friday", "DTSTART;TZID=Europe/Berlin:20261030T160000",
+					"RRULE:FREQ=MONTHLY;BYDAY=-1FR"),
+			},
+			want: []string{
+				"2026-10-05 10:00 CEST First Monday",
+				"2026-10-30 16:00 CET Last Friday",
+				"2026-11-02 10:00 CET First Monday",
+				"2026-11-27 16:00 CET Last Friday",
+				"2026-12-07 10:00 CET First Monday",
+				"2026-12-25 16:00 CET Last Friday",
+			},
+		},
+		{
+			name: "monthly BYSETPOS and BYMONTHDAY",
+			events: []string{
+				event("UID:1", "SUMMARY:Last workday", "DTSTART;TZID=Europe/Berlin:20261030T170000",
+					"RRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1;COUNT=2"),
+				event("UID:2", "SUMMARY:Month end", "DTSTART;TZID=Europe/Berlin:20261031T090000",
+					"RRULE:FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=2"),
+				event("UID:3", "SUMMARY:The 31st", "DTSTART;TZID=Europe/Berlin:20261031T120000",
+					"RRULE:FREQ=MONTHLY;COUNT=2"),
+			},
+			want: []string{
+				"2026-10-30 17:00 CET Last workday",
+				"2026-10-31 09:00 CET Month end",
+				"2026-10-31 12:00 CET The 31st",
+				"2026-11-30 09:00 CET Month end",
+				"2026-11-30 17:00 CET Last workday",
+				"2026-12-31 12:00 CET The 31st",
+			},
+		},
+		{
+			name: "yearly",
+			events: []string{event("UID:1", "SUMMARY:Review", "DTSTART;TZID=Europe/Berlin:20251020T100000",
+				"RRULE:FREQ=YEARLY;BYMONTH=10;BYMONTHDAY=20")},
+			want: []string{"2026-10-20 10:00 CEST Review"},
+		},
+		{
+			name: "unsupported rules are skipped, not guessed",
+			events: []string{
+				event("UID:1", "SUMMARY:Hourly", "DTSTART:20261005T080000Z", "RRULE:FREQ=HOURLY"),
+				event("UID:2", "SUMMARY:Week 42", "DTSTART:20261005T080000Z", "RRULE:FREQ=YEARLY;BYWEEKNO=42"),
+				event("UID:3", "SUMMARY:New year", "DTSTART:20260105T080000Z", "RRULE:FREQ=YEARLY;BYDAY=1MO;BYMONTH=1"),
+				event("UID:4", "SUMMARY:Weekly ordinal", "DTSTART:20261005T080000Z", "RRULE:FREQ=WEEKLY;BYDAY=1MO"),
+				event("UID:5", "SUMMARY:Bad count", "DTSTART:20261005T080000Z", "RRULE:FREQ=DAILY;COUNT=many"),
+				event("UID:6", "SUMMARY:Bad until", "DTSTART:20261005T080000Z", "RRULE:FREQ=DAILY;UNTIL=soon"),
+				event("UID:7", "SUMMARY:Bad move", "RECURRENCE-ID:yesterday", "DTSTART:20261005T080000Z"),
+				event("UID:8", "SUMMARY:Kept", "DTSTART:20261005T080000Z"),
+			},
+			want:    []string{"2026-10-05 10:00 CEST Kept"},
+			skipped: 7,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, skipped, err := parse(strings.NewReader(ics(tt.events...)))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if len(skipped) != tt.skipped {
+				t.Errorf("skipped %q, want %d events", skipped, tt.skipped)
+			}
+			start, end := from, to
+			if !tt.from.IsZero() {
+				start, end = tt.from, tt.to
+			}
+			var got []string
+			for _, ev := range expand(events, start, end) {
+				got = append(got, ev.Start.In(berlin).Format("2006-01-02 15:04 MST ")+ev.Summary)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("occurrences =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestCalendarReportsSkippedEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "work.ics")
+	data := ics(
+		event("UID:1", "SUMMARY:Hourly", "DTSTART:20261005T080000Z", "RRULE:FREQ=HOURLY"),
+		event("UID:2", "SUMMARY:Standup", "DTSTART:20261005T080000Z", "DURATION:PT15M", "RRULE:FREQ=DAILY"),
+	)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cal := New(path)
+	now := time.Date(2026, 10, 7, 8, 5, 0, 0, time.UTC)
+	if ev, ok := cal.Current(now); !ok || ev.Summary != "Standup" {
+		t.Errorf("Current = %+v, %v, want the standup", ev, ok)
+	}
+	err := cal.TakeError()
+	if err == nil || !strings.Contains(err.Error(), `skipped "Hourly": unsupported recurrence`) {
+		t.Errorf("TakeError = %v, want the skipped event", err)
+	}
+
+	// Reported once, not on every look at the file
+	cal.checked = time.Time{}
+	cal.modTime = time.Time{}
+	cal.Current(now)
+	if err := cal.TakeError(); err != nil {
+		t.Errorf("TakeError = %v again", err)
+	}
+}
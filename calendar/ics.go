@@ -0,0 +1,252 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// vevent is an event as read from the file, before recurrences are expanded
+type vevent struct {
+	uid          string
+	summary      string
+	start        time.Time
+	duration     time.Duration
+	rule         *rrule
+	exdates      map[int64]bool // Unix start times of cancelled occurrences
+	recurrenceID time.Time      // Set on a moved occurrence of a recurring event
+}
+
+// property is one content line, e.g. DTSTART;TZID=Europe/Berlin:20261018T090000
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// parse reads the meetings in an iCalendar stream. All-day, cancelled and
+// free (transparent) events are left out since they don't block focus time.
+// Events that can't be read, such as ones with an unsupported recurrence,
+// are left out too and listed in skipped, so the others still count.
+func parse(r io.Reader) (events []vevent, skipped []error, err error) {
+	var (
+		current map[string][]property
+		depth   int // Nesting inside the VEVENT, e.g. VALARM
+	)
+	err = unfold(r, func(line string) error {
+		prop := parseProperty(line)
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			current = make(map[string][]property)
+		case current == nil:
+		case prop.name == "BEGIN":
+			depth++
+		case prop.name == "END" && depth > 0:
+			depth--
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if ev, ok, err := newVevent(current); err != nil {
+				skipped = append(skipped, fmt.Errorf("%s: %w", eventName(current), err))
+			} else if ok {
+				events = append(events, ev)
+			}
+			current = nil
+		case depth == 0:
+			current[prop.name] = append(current[prop.name], prop)
+		}
+		return nil
+	})
+	return events, skipped, err
+}
+
+// eventName names an event in errors, by its summary or else its UID
+func eventName(props map[string][]property) string {
+	if summary, ok := first(props, "SUMMARY"); ok && summary.value != "" {
+		return strconv.Quote(unescape(summary.value))
+	}
+	if uid, ok := first(props, "UID"); ok {
+		return "event " + uid.value
+	}
+	return "an event without a UID"
+}
+
+// unfold calls fn with each logical line, joining folded continuation lines
+func unfold(r io.Reader, fn func(string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var line string
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t") {
+			line += text[1:]
+			continue
+		}
+		if line != "" {
+			if err := fn(line); err != nil {
+				return err
+			}
+		}
+		line = text
+	}
+	if line != "" {
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func parseProperty(line string) property {
+	// The value starts at the first colon outside a quoted parameter
+	quoted := false
+	split := len(line)
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			split = i
+			break
+		}
+	}
+	prop := property{params: make(map[string]string)}
+	if split < len(line) {
+		prop.value = line[split+1:]
+	}
+	parts := strings.Split(line[:split], ";")
+	prop.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return prop
+}
+
+func first(props map[string][]property, name string) (property, bool) {
+	if list := props[name]; len(list) > 0 {
+		return list[0], true
+	}
+	return property{}, false
+}
+
+func newVevent(props map[string][]property) (vevent, bool, error) {
+	var ev vevent
+	if status, ok := first(props, "STATUS"); ok && strings.EqualFold(status.value, "CANCELLED") {
+		return ev, false, nil
+	}
+	if transp, ok := first(props, "TRANSP"); ok && strings.EqualFold(transp.value, "TRANSPARENT") {
+		return ev, false, nil
+	}
+	dtstart, ok := first(props, "DTSTART")
+	if !ok || isDate(dtstart) {
+		return ev, false, nil // All-day
+	}
+
+	start, err := parseTime(dtstart)
+	if err != nil {
+		return ev, false, err
+	}
+	ev.start = start
+	if dtend, ok := first(props, "DTEND"); ok {
+		end, err := parseTime(dtend)
+		if err != nil {
+			return ev, false, err
+		}
+		ev.duration = end.Sub(start)
+	} else if duration, ok := first(props, "DURATION"); ok {
+		if ev.duration, err = parseDuration(duration.value); err != nil {
+			return ev, false, err
+		}
+	}
+
+	if uid, ok := first(props, "UID"); ok {
+		ev.uid = uid.value
+	}
+	if summary, ok := first(props, "SUMMARY"); ok {
+		ev.summary = unescape(summary.value)
+	}
+	if ev.summary == "" {
+		ev.summary = "Busy"
+	}
+	if id, ok := first(props, "RECURRENCE-ID"); ok {
+		if ev.recurrenceID, err = parseTime(id); err != nil {
+			return ev, false, err
+		}
+	}
+	if rule, ok := first(props, "RRULE"); ok {
+		if ev.rule, err = parseRRule(rule.value, start); err != nil {
+			return ev, false, err
+		}
+	}
+	for _, exdate := range props["EXDATE"] {
+		for _, value := range strings.Split(exdate.value, ",") {
+			t, err := parseTime(property{params: exdate.params, value: value})
+			if err != nil {
+				return ev, false, err
+			}
+			if ev.exdates == nil {
+				ev.exdates = make(map[int64]bool)
+			}
+			ev.exdates[t.Unix()] = true
+		}
+	}
+	return ev, true, nil
+}
+
+func isDate(prop property) bool {
+	return strings.EqualFold(prop.params["VALUE"], "DATE") || len(prop.value) == len("20060102")
+}
+
+// parseTime reads a DATE-TIME in UTC, in its TZID or floating in local time.
+// Unknown time zones, such as Windows names, are taken as local time.
+func parseTime(prop property) (time.Time, error) {
+	value := strings.TrimSpace(prop.value)
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse("20060102T150405Z", value)
+	}
+	loc := time.Local
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = l
+		}
+	}
+	if len(value) == len("20060102") {
+		return time.ParseInLocation("20060102", value, loc)
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return t, fmt.Errorf("invalid %s time %q", prop.name, value)
+	}
+	return t, nil
+}
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration reads an iCalendar duration such as PT1H30M
+func parseDuration(value string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	var d time.Duration
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	for i, unit := range units {
+		if m[i+2] != "" {
+			n, _ := strconv.Atoi(m[i+2])
+			d += time.Duration(n) * unit
+		}
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+var textUnescaper = strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescape(s string) string {
+	return textUnescaper.Replace(s)
+}
@@ -0,0 +1,296 @@
+package calendar
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rrule is the subset of RFC 5545 recurrence rules that work calendars use
+// for meetings: a frequency and interval, COUNT or UNTIL, BYDAY weekdays for
+// daily and weekly events, and BYDAY (with ordinals such as 1MO or -1FR),
+// BYMONTHDAY and BYSETPOS for monthly ones. Yearly rules may only repeat
+// DTSTART's month and day. Rules using anything else are rejected rather
+// than expanded on the wrong days.
+type rrule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	weekStart  time.Weekday
+	byDay      []byDay
+	byMonthDay []int // Negative counts from the end of the month
+	bySetPos   []int
+}
+
+// byDay is a BYDAY entry: a weekday, and for monthly rules which one of
+// the month, e.g. 2 for the second or -1 for the last; 0 means every one
+type byDay struct {
+	nth int
+	day time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+func parseRRule(value string, dtstart time.Time) (*rrule, error) {
+	r := &rrule{interval: 1, weekStart: time.Monday}
+	var byMonth []int
+	unsupported := fmt.Errorf("unsupported recurrence %q", value)
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			if r.interval, err = strconv.Atoi(val); err != nil || r.interval < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL in recurrence %q", value)
+			}
+		case "COUNT":
+			if r.count, err = strconv.Atoi(val); err != nil || r.count < 1 {
+				return nil, fmt.Errorf("invalid COUNT in recurrence %q", value)
+			}
+		case "UNTIL":
+			until, err := parseTime(property{name: "UNTIL", value: val})
+			if err != nil {
+				return nil, err
+			}
+			if len(val) == len("20060102") {
+				until = time.Date(until.Year(), until.Month(), until.Day(), 23, 59, 59, 0, dtstart.Location())
+			}
+			r.until = until
+		case "WKST":
+			wd, ok := weekdays[strings.ToUpper(val)]
+			if !ok {
+				return nil, unsupported
+			}
+			r.weekStart = wd
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				name := strings.TrimLeft(day, "+-0123456789")
+				wd, ok := weekdays[strings.ToUpper(name)]
+				if !ok {
+					return nil, unsupported
+				}
+				entry := byDay{day: wd}
+				if ordinal := day[:len(day)-len(name)]; ordinal != "" {
+					if entry.nth, err = strconv.Atoi(ordinal); err != nil || entry.nth == 0 || entry.nth < -5 || entry.nth > 5 {
+						return nil, unsupported
+					}
+				}
+				r.byDay = append(r.byDay, entry)
+			}
+		case "BYMONTHDAY":
+			if r.byMonthDay, err = parseNumbers(val, 31); err != nil {
+				return nil, unsupported
+			}
+		case "BYMONTH":
+			if byMonth, err = parseNumbers(val, 12); err != nil {
+				return nil, unsupported
+			}
+		case "BYSETPOS":
+			if r.bySetPos, err = parseNumbers(val, 366); err != nil {
+				return nil, unsupported
+			}
+		default:
+			// BYWEEKNO, BYYEARDAY, BYHOUR and the like
+			return nil, unsupported
+		}
+	}
+
+	switch r.freq {
+	case "DAILY", "WEEKLY":
+		// Ordinals only mean something within a month
+		for _, d := range r.byDay {
+			if d.nth != 0 {
+				return nil, unsupported
+			}
+		}
+		if r.byMonthDay != nil || r.bySetPos != nil || byMonth != nil {
+			return nil, unsupported
+		}
+	case "MONTHLY":
+		if byMonth != nil {
+			return nil, unsupported
+		}
+	case "YEARLY":
+		// Outlook spells out the month and day it repeats on
+		if r.byDay != nil || r.bySetPos != nil ||
+			!onlyNumber(byMonth, int(dtstart.Month())) || !onlyNumber(r.byMonthDay, dtstart.Day()) {
+			return nil, unsupported
+		}
+		r.byMonthDay = nil
+	default:
+		return nil, unsupported
+	}
+	return r, nil
+}
+
+// parseNumbers reads a comma-separated list of non-zero numbers within
+// [-limit, limit]
+func parseNumbers(value string, limit int) ([]int, error) {
+	var numbers []int
+	for _, s := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimPrefix(s, "+"))
+		if err != nil || n == 0 || n < -limit || n > limit {
+			return nil, fmt.Errorf("invalid number %q", s)
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+// onlyNumber reports whether numbers is empty or just n
+func onlyNumber(numbers []int, n int) bool {
+	return len(numbers) == 0 || (len(numbers) == 1 && numbers[0] == n)
+}
+
+// each calls fn with the start of every occurrence from dtstart on, in
+// order, until fn returns false or the rule ends
+func (r *rrule) each(dtstart time.Time, fn func(time.Time) bool) {
+	n := 0
+	emit := func(t time.Time) bool {
+		if t.Before(dtstart) {
+			return true
+		}
+		if !r.until.IsZero() && t.After(r.until) {
+			return false
+		}
+		if r.count > 0 && n >= r.count {
+			return false
+		}
+		n++
+		return fn(t)
+	}
+
+	// Safety net against rules that never produce a date
+	for step := 0; step < 100000; step++ {
+		k := step * r.interval
+		switch r.freq {
+		case "DAILY":
+			t := dtstart.AddDate(0, 0, k)
+			if len(r.byDay) > 0 && !r.hasWeekday(t.Weekday()) {
+				if !r.until.IsZero() && t.After(r.until) {
+					return
+				}
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		case "WEEKLY":
+			if len(r.byDay) == 0 {
+				if !emit(dtstart.AddDate(0, 0, 7*k)) {
+					return
+				}
+				continue
+			}
+			weekStart := dtstart.AddDate(0, 0, 7*k-(int(dtstart.Weekday()-r.weekStart)+7)%7)
+			for offset := 0; offset < 7; offset++ {
+				t := weekStart.AddDate(0, 0, offset)
+				if r.hasWeekday(t.Weekday()) && !emit(t) {
+					return
+				}
+			}
+		case "MONTHLY":
+			days := r.monthDays(dtstart, k)
+			if len(days) == 0 && !r.until.IsZero() {
+				month := time.Date(dtstart.Year(), dtstart.Month()+time.Month(k), 1, 0, 0, 0, 0, dtstart.Location())
+				if month.After(r.until) {
+					return
+				}
+			}
+			for _, day := range days {
+				t := time.Date(dtstart.Year(), dtstart.Month()+time.Month(k), day,
+					dtstart.Hour(), dtstart.Minute(), dtstart.Second(), dtstart.Nanosecond(), dtstart.Location())
+				if !emit(t) {
+					return
+				}
+			}
+		case "YEARLY":
+			t := dtstart.AddDate(k, 0, 0)
+			if t.Day() != dtstart.Day() {
+				continue // No February 29th this year
+			}
+			if !emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// monthDays returns the days of the month k months after dtstart's that
+// the rule picks, in order
+func (r *rrule) monthDays(dtstart time.Time, k int) []int {
+	first := time.Date(dtstart.Year(), dtstart.Month()+time.Month(k), 1, 0, 0, 0, 0, time.UTC)
+	length := first.AddDate(0, 1, -1).Day()
+
+	var days []int
+	switch {
+	case r.byDay == nil && r.byMonthDay == nil:
+		// DTSTART's day, when the month has one, e.g. the 31st
+		if dtstart.Day() <= length {
+			days = []int{dtstart.Day()}
+		}
+	default:
+		for day := 1; day <= length; day++ {
+			if r.onMonthDay(first.AddDate(0, 0, day-1), length) {
+				days = append(days, day)
+			}
+		}
+	}
+	if r.bySetPos == nil {
+		return days
+	}
+	var picked []int
+	for _, pos := range r.bySetPos {
+		if pos < 0 {
+			pos += len(days) + 1
+		}
+		if pos >= 1 && pos <= len(days) {
+			picked = append(picked, days[pos-1])
+		}
+	}
+	slices.Sort(picked)
+	return slices.Compact(picked)
+}
+
+// onMonthDay reports whether the rule picks day, in a month of length days
+func (r *rrule) onMonthDay(day time.Time, length int) bool {
+	if r.byMonthDay != nil {
+		matched := false
+		for _, n := range r.byMonthDay {
+			if n == day.Day() || n == day.Day()-length-1 {
+				matched = true
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if r.byDay == nil {
+		return true
+	}
+	nth := (day.Day()-1)/7 + 1             // Second Tuesday: 2
+	fromEnd := -((length-day.Day())/7 + 1) // Last Tuesday: -1
+	for _, d := range r.byDay {
+		if d.day == day.Weekday() && (d.nth == 0 || d.nth == nth || d.nth == fromEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rrule) hasWeekday(day time.Weekday) bool {
+	for _, d := range r.byDay {
+		if d.day == day {
+			return true
+		}
+	}
+	return false
+}
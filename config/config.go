@@ -5,19 +5,52 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 )
 
 type Config struct {
-	Volume     float64 `json:"volume"`
-	configFile string
-	mu         sync.Mutex
+	Volume          float64           `json:"volume"`
+	WindDownMinutes int               `json:"wind_down_minutes"` // Ambient audio after the last cycle, 0 disables
+	FadeOutSeconds  int               `json:"fade_out_seconds"`  // Length of the fade at the end of a wind-down
+	ToneCarrierHz   float64           `json:"tone_carrier_hz"`   // Custom generated tone, offered when ToneBeatHz > 0
+	ToneBeatHz      float64           `json:"tone_beat_hz"`
+	Hooks           map[string]string `json:"hooks,omitempty"` // Shell command per timer event, e.g. "focus_start"
+	HookTimeoutSecs int               `json:"hook_timeout_seconds"`
+	Webhooks        []Webhook         `json:"webhooks,omitempty"`
+	NotifyDesktop   bool              `json:"notify_desktop"`      // Desktop notifications at phase changes
+	NotifyTerminal  bool              `json:"notify_terminal"`     // OSC 9/777 when there is no desktop
+	NotifyBell      bool              `json:"notify_bell"`         // Terminal bell when there is no desktop
+	HTTPAddr        string            `json:"http_addr,omitempty"` // Local HTTP API, e.g. "127.0.0.1:7878"
+	HTTPOrigins     []string          `json:"http_allowed_origins,omitempty"`
+	TodoTxt         string            `json:"todo_txt,omitempty"`     // Task list file instead of tasks.json
+	Taskwarrior     bool              `json:"taskwarrior"`            // Take tasks from Taskwarrior, overrides TodoTxt
+	Timewarrior     bool              `json:"timewarrior"`            // Track focus sessions with timew
+	OrgFile         string            `json:"org_file,omitempty"`     // Org file to clock focus sessions in
+	OrgHeading      string            `json:"org_heading,omitempty"`  // Heading to clock under, default the task
+	CalendarICS     string            `json:"calendar_ics,omitempty"` // Focus phases end before its meetings
+	Plan            string            `json:"plan,omitempty"`         // Blocks of the day, e.g. "09:00 deep work 90m, break 15m"
+	configFile      string
+	mu              sync.Mutex
+}
+
+// Webhook is an HTTP endpoint notified of timer events
+type Webhook struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"` // Signs the payload, see webhooks.Sign
+	Events []string `json:"events,omitempty"` // Empty means every event
 }
 
 func NewConfig(configDir string) *Config {
 	c := &Config{
-		configFile: filepath.Join(configDir, ".zoneout_config"),
-		Volume:     0.5, // Default 50%
+		configFile:      filepath.Join(configDir, ".zoneout_config"),
+		Volume:          0.5, // Default 50%
+		FadeOutSeconds:  60,
+		HookTimeoutSecs: 10,
+		NotifyDesktop:   true,
+		NotifyTerminal:  true,
+		NotifyBell:      true,
 	}
 	c.Load()
 	return c
@@ -68,3 +101,123 @@ func (c *Config) GetVolume() float64 {
 	defer c.mu.Unlock()
 	return c.Volume
 }
+
+func (c *Config) GetWindDown() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Duration(c.WindDownMinutes) * time.Minute
+}
+
+func (c *Config) GetFadeOut() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Duration(c.FadeOutSeconds) * time.Second
+}
+
+// GetCustomTone returns the configured tone frequencies; beat is 0 when unset
+func (c *Config) GetCustomTone() (carrier, beat float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ToneCarrierHz, c.ToneBeatHz
+}
+
+// GetHooks returns a copy of the configured event hooks
+func (c *Config) GetHooks() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hooks := make(map[string]string, len(c.Hooks))
+	for event, command := range c.Hooks {
+		hooks[event] = command
+	}
+	return hooks
+}
+
+func (c *Config) GetHookTimeout() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Duration(c.HookTimeoutSecs) * time.Second
+}
+
+// GetWebhooks returns a copy of the configured webhooks
+func (c *Config) GetWebhooks() []Webhook {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	webhooks := make([]Webhook, len(c.Webhooks))
+	copy(webhooks, c.Webhooks)
+	return webhooks
+}
+
+// GetNotifications reports which notification backends are enabled
+func (c *Config) GetNotifications() (desktop, terminal, bell bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.NotifyDesktop, c.NotifyTerminal, c.NotifyBell
+}
+
+// GetHTTP returns the HTTP API address, empty when disabled, and the
+// browser origins allowed to use it
+func (c *Config) GetHTTP() (addr string, origins []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.HTTPAddr, append([]string(nil), c.HTTPOrigins...)
+}
+
+// GetTodoTxt returns the todo.txt file to use for tasks, with a leading ~
+// expanded, or "" for the built-in task list
+func (c *Config) GetTodoTxt() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return expandHome(c.TodoTxt)
+}
+
+// GetOrgClock returns the Org file to add CLOCK lines to, "" when off, and
+// the heading to add them under
+func (c *Config) GetOrgClock() (file, heading string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return expandHome(c.OrgFile), c.OrgHeading
+}
+
+// GetPlan returns the planned blocks as text, "" for the default cycle
+func (c *Config) GetPlan() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Plan
+}
+
+// SetPlan saves the planned blocks, "" for the default cycle
+func (c *Config) SetPlan(plan string) error {
+	c.mu.Lock()
+	c.Plan = plan
+	c.mu.Unlock()
+	return c.Save()
+}
+
+// GetCalendarICS returns the .ics file with the meetings to plan focus
+// around, "" when off
+func (c *Config) GetCalendarICS() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return expandHome(c.CalendarICS)
+}
+
+// expandHome expands a leading ~ to the home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// GetWarriors reports whether Taskwarrior supplies the tasks and whether
+// Timewarrior tracks the focus sessions
+func (c *Config) GetWarriors() (taskwarrior, timewarrior bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Taskwarrior, c.Timewarrior
+}
@@ -0,0 +1,286 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Commands understood by a running instance
+const (
+	CommandStart       = "start"
+	CommandPause       = "pause"
+	CommandResume      = "resume"
+	CommandToggle      = "toggle"
+	CommandSkip        = "skip"
+	CommandReset       = "reset"
+	CommandSetVolume   = "set-volume"
+	CommandSelectSound = "select-sound"
+	CommandStatus      = "status"
+	CommandListSounds  = "list-sounds"
+	CommandStats       = "stats"
+	CommandShutdown    = "shutdown"
+	CommandListTasks   = "list-tasks"
+	CommandAddTask     = "add-task"
+	CommandSelectTask  = "select-task"
+	CommandDoneTask    = "done-task"
+	CommandReopenTask  = "reopen-task"
+	CommandEditTask    = "edit-task"
+	CommandEstimate    = "set-estimate"
+	CommandInterrupt   = "interrupt"
+	CommandSetTags     = "set-tags"
+	CommandSetPlan     = "set-plan"
+)
+
+// replyTimeout bounds how long a client waits for the UI to handle a command
+const replyTimeout = 2 * time.Second
+
+// Request is one command, sent as a single line of JSON
+type Request struct {
+	Command string  `json:"command"`
+	Volume  float64 `json:"volume,omitempty"` // set-volume, 0.0 to 1.0
+	Sound   string  `json:"sound,omitempty"`  // select-sound, matched against file and tone names
+
+	Task     string   `json:"task,omitempty"`     // add-task: the title; others: ID or part of the title, "" for no task
+	Text     string   `json:"text,omitempty"`     // edit-task: the task as add-task takes it
+	Estimate int      `json:"estimate,omitempty"` // set-estimate: pomodoros, 0 for none
+	Kind     string   `json:"kind,omitempty"`     // interrupt: "internal" or "external"
+	Note     string   `json:"note,omitempty"`     // interrupt
+	Tags     []string `json:"tags,omitempty"`     // set-tags, replacing those picked before
+	Plan     string   `json:"plan,omitempty"`     // set-plan, "" for the default cycle
+}
+
+// Response answers a Request, also as a single line of JSON
+type Response struct {
+	OK     bool     `json:"ok"`
+	Error  string   `json:"error,omitempty"`
+	Status *Status  `json:"status,omitempty"`
+	Sounds []string `json:"sounds,omitempty"` // list-sounds: MP3 file names, then tone names
+	Tones  []string `json:"tones,omitempty"`  // list-sounds: the tone names again
+	Stats  *Stats   `json:"stats,omitempty"`  // stats
+	Tasks  []Task   `json:"tasks,omitempty"`  // list-tasks
+}
+
+// Task is one entry of the task list
+type Task struct {
+	ID        string   `json:"id"`
+	Title     string   `json:"title"`
+	Done      bool     `json:"done,omitempty"`
+	Active    bool     `json:"active,omitempty"`
+	Pomodoros int      `json:"pomodoros"`
+	Estimate  int      `json:"estimate,omitempty"`
+	Priority  string   `json:"priority,omitempty"`
+	Projects  []string `json:"projects,omitempty"`
+	Contexts  []string `json:"contexts,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Input     string   `json:"input,omitempty"` // The task as add-task and edit-task take it
+}
+
+// Status is a snapshot of the timer and audio state
+type Status struct {
+	Mode                  string   `json:"mode"` // "focus", "break" or "idle"
+	Running               bool     `json:"running"`
+	Paused                bool     `json:"paused"`
+	Remaining             string   `json:"remaining"` // MM:SS as shown in the TUI
+	RemainingSeconds      int      `json:"remaining_seconds"`
+	TotalSeconds          int      `json:"total_seconds"`
+	Session               int      `json:"session"`
+	TotalSessions         int      `json:"total_sessions"`
+	Block                 string   `json:"block,omitempty"` // Label of the planned block
+	BlockIndex            int      `json:"block_index"`     // Of the block in Plan, -1 when edited out of it
+	CompletedSessions     int      `json:"completed_sessions"`
+	Pauses                int      `json:"pauses"` // Since the instance started
+	Skips                 int      `json:"skips"`
+	Task                  string   `json:"task,omitempty"` // Active task
+	TaskPomodoros         int      `json:"task_pomodoros,omitempty"`
+	TaskEstimate          int      `json:"task_estimate,omitempty"`
+	InternalInterruptions int      `json:"internal_interruptions"` // Of the focus session
+	ExternalInterruptions int      `json:"external_interruptions"`
+	Tags                  []string `json:"tags,omitempty"`         // Recorded with the focus session
+	SessionTags           []string `json:"session_tags,omitempty"` // Picked with # or set-tags, without the task's
+	Plan                  string   `json:"plan,omitempty"`         // The planned blocks
+	Sound                 string   `json:"sound"`
+	Tone                  string   `json:"tone,omitempty"`
+	Volume                float64  `json:"volume"`
+	TodaySessions         int      `json:"today_sessions"`
+	Badge                 string   `json:"badge"`
+	BadgeDescription      string   `json:"badge_description"`
+	Message               string   `json:"message,omitempty"`   // Message of the day
+	Meeting               *Meeting `json:"meeting,omitempty"`   // In progress, or the next one in 12 hours
+	WindDown              string   `json:"wind_down,omitempty"` // Label of the wind-down or sleep timer running
+	WindDownSeconds       int      `json:"wind_down_seconds,omitempty"`
+	Team                  *Team    `json:"team,omitempty"`
+	Player                string   `json:"player"` // Audio player found, "" for none
+	EmbeddedSounds        bool     `json:"embedded_sounds"`
+	AudioError            string   `json:"audio_error,omitempty"` // Last playback failure
+}
+
+// Meeting is an event of the calendar focus phases end before
+type Meeting struct {
+	Summary string    `json:"summary"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+}
+
+// Team describes the team timer the instance hosts or follows
+type Team struct {
+	Host      bool     `json:"host"` // False when following a host
+	Addr      string   `json:"addr"`
+	Connected bool     `json:"connected"`
+	Peers     []string `json:"peers,omitempty"`
+}
+
+// Stats summarizes the completed focus sessions
+type Stats struct {
+	TotalSessions     int    `json:"total_sessions"`
+	TodaySessions     int    `json:"today_sessions"`
+	TotalFocusMinutes int    `json:"total_focus_minutes"`
+	LastSessionDate   string `json:"last_session_date,omitempty"`
+	Badge             string `json:"badge"`
+	BadgeDescription  string `json:"badge_description"`
+}
+
+// CommandMsg delivers a Request to the Bubble Tea program. The model must
+// call Reply exactly once.
+type CommandMsg struct {
+	Request Request
+	reply   chan Response
+}
+
+// Reply sends the response back to the waiting client
+func (m CommandMsg) Reply(resp Response) {
+	m.reply <- resp
+}
+
+// Dispatch hands req to the program through send and waits for the reply.
+// Sending counts towards the timeout too, as it blocks while the program is
+// busy.
+func Dispatch(send func(msg interface{}), req Request) Response {
+	msg := CommandMsg{Request: req, reply: make(chan Response, 1)}
+	go send(msg)
+
+	select {
+	case resp := <-msg.reply:
+		return resp
+	case <-time.After(replyTimeout):
+		return Response{Error: "timed out waiting for zoneout"}
+	}
+}
+
+// SocketPath is where a running instance listens
+func SocketPath(configDir string) string {
+	return filepath.Join(configDir, "zoneout.sock")
+}
+
+// Server accepts control connections on a Unix domain socket
+type Server struct {
+	listener net.Listener
+	path     string
+	send     func(msg interface{})
+}
+
+// Listen creates the control socket. It refuses to replace the socket of
+// another live instance but removes one left behind by a crash.
+func Listen(path string, send func(msg interface{})) (*Server, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another zoneout instance is listening on %s", path)
+		}
+		os.Remove(path)
+	}
+
+	// Only the owner may drive the timer. The socket is created in a private
+	// directory and moved into place once restricted, so no one else can
+	// connect in between.
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".zoneout-sock-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create control socket: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, filepath.Base(path))
+
+	listener, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create control socket: %w", err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false) // Close removes path
+	if err := os.Chmod(tmp, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict control socket: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to create control socket: %w", err)
+	}
+
+	return &Server{listener: listener, path: path, send: send}, nil
+}
+
+// Serve accepts connections until Close is called. Each connection may send
+// any number of requests, one JSON object per line.
+func (s *Server) Serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req Request
+		var resp Response
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp = Response{Error: fmt.Sprintf("invalid request: %v", err)}
+		} else {
+			resp = Dispatch(s.send, req)
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// Send connects to the instance listening on path, sends req and returns
+// its response
+func Send(path string, req Request) (Response, error) {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return Response{}, fmt.Errorf("zoneout is not running (%w)", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(replyTimeout + time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, fmt.Errorf("failed to send command: %w", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return Response{}, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp, nil
+}
+
+// Close stops accepting connections and removes the socket file
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	os.Remove(s.path)
+	return err
+}
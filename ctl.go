@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"zoneout/control"
+)
+
+const ctlUsage = `Usage: zoneout ctl <command> [args]
+
+Commands:
+  start            Start a cycle, or resume if paused
+  pause            Pause the timer
+  resume           Resume the timer
+  toggle           Start, pause or resume (like SPACE)
+  skip             Skip to the next phase
+  reset            Reset the cycle
+  volume <0-100>   Set the volume
+  sound <name>     Play the sound or tone whose name contains <name>
+  status [--json]  Print the current status
+  tasks            List the tasks
+  task [<name>]    Work on the task whose ID or title contains <name>, or none
+  add-task <title> Add a task, end the title with ~N to estimate it
+  done <name>      Mark the task whose ID or title contains <name> as done
+  interrupt internal|external [<note>]
+                   Log an interruption of the focus session
+  tags [<tag>...]  Set the tags of the focus session, none to clear them
+  plan [<plan>]    Plan the blocks, e.g. "deep work 90m, break 15m", or
+                   restore the default cycle
+`
+
+// runCtl sends one command to the running instance and prints the result
+func runCtl(args []string, configDir string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, ctlUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("ctl "+args[0], flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the status as JSON")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, ctlUsage) }
+	fs.Parse(args[1:])
+
+	req := control.Request{Command: args[0]}
+	switch args[0] {
+	case control.CommandStart, control.CommandPause, control.CommandResume, control.CommandToggle,
+		control.CommandSkip, control.CommandReset, control.CommandStatus:
+	case "volume":
+		percent, err := strconv.Atoi(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Usage: zoneout ctl volume <0-100>")
+			return 2
+		}
+		req.Command = control.CommandSetVolume
+		req.Volume = float64(percent) / 100
+	case "sound":
+		if fs.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "Usage: zoneout ctl sound <name>")
+			return 2
+		}
+		req.Command = control.CommandSelectSound
+		req.Sound = strings.Join(fs.Args(), " ")
+	case "tasks":
+		req.Command = control.CommandListTasks
+	case "task":
+		req.Command = control.CommandSelectTask
+		req.Task = strings.Join(fs.Args(), " ")
+	case "add-task", "done":
+		if fs.NArg() == 0 {
+			fmt.Fprintf(os.Stderr, "Usage: zoneout ctl %s <task>\n", args[0])
+			return 2
+		}
+		req.Command = control.CommandAddTask
+		if args[0] == "done" {
+			req.Command = control.CommandDoneTask
+		}
+		req.Task = strings.Join(fs.Args(), " ")
+	case "interrupt":
+		if fs.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "Usage: zoneout ctl interrupt internal|external [<note>]")
+			return 2
+		}
+		req.Command = control.CommandInterrupt
+		req.Kind = fs.Arg(0)
+		req.Note = strings.Join(fs.Args()[1:], " ")
+	case "tags":
+		req.Command = control.CommandSetTags
+		req.Tags = fs.Args()
+	case "plan":
+		req.Command = control.CommandSetPlan
+		req.Plan = strings.Join(fs.Args(), " ")
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown ctl command: %s\n\n%s", args[0], ctlUsage)
+		return 2
+	}
+
+	resp, err := control.Send(control.SocketPath(configDir), req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if !resp.OK {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", resp.Error)
+		return 1
+	}
+	if req.Command == control.CommandListTasks {
+		for _, t := range resp.Tasks {
+			fmt.Println(formatTaskLine(t))
+		}
+		return 0
+	}
+	if resp.Status == nil {
+		return 0
+	}
+
+	if *asJSON {
+		data, _ := json.MarshalIndent(resp.Status, "", "  ")
+		fmt.Println(string(data))
+		return 0
+	}
+	fmt.Println(formatStatusLine(resp.Status))
+	return 0
+}
+
+// formatStatusLine renders a status as one human-readable line
+func formatStatusLine(s *control.Status) string {
+	state := "idle"
+	if s.Running {
+		state = "running"
+	} else if s.Paused {
+		state = "paused"
+	}
+
+	line := fmt.Sprintf("%s %s (%s) | session %d of %d | volume %d%%",
+		strings.ToUpper(s.Mode), s.Remaining, state, s.Session, s.TotalSessions, int(s.Volume*100))
+	if s.Sound != "" {
+		line += " | " + s.Sound
+	}
+	if s.Tone != "" {
+		line += " + " + s.Tone
+	}
+	return line
+}
+
+// formatTaskLine renders a task as one line of the task list
+func formatTaskLine(t control.Task) string {
+	check := "[ ]"
+	if t.Done {
+		check = "[x]"
+	} else if t.Active {
+		check = "[●]"
+	}
+	pomodoros := strconv.Itoa(t.Pomodoros)
+	if t.Estimate > 0 {
+		pomodoros += "/" + strconv.Itoa(t.Estimate)
+	}
+	return fmt.Sprintf("%s %s  %s 🍅  (%s)", check, t.Title, pomodoros, t.ID)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"zoneout/audio"
+	"zoneout/control"
+)
+
+// runDaemon runs the timer without a terminal. The Pomodoro, audio and stats
+// live here; dashboards attach with `zoneout attach` (or plain `zoneout`)
+// and can come and go without interrupting the session.
+func runDaemon(args []string, configDir string) int {
+	socketPath := control.SocketPath(configDir)
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "stop":
+			if _, err := control.Send(socketPath, control.Request{Command: control.CommandShutdown}); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			return 0
+		default:
+			fmt.Fprintln(os.Stderr, "Usage: zoneout daemon [stop]")
+			return 2
+		}
+	}
+
+	// Kill any player still running when we leave, whatever the reason
+	defer audio.KillAll()
+
+	// No terminal to notify through, desktop notifications only
+	a, err := newApp(configDir, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer a.Close()
+
+	// Same model as the TUI, minus the terminal
+	p := tea.NewProgram(a.model, tea.WithoutRenderer(), tea.WithInput(nil), tea.WithoutSignalHandler())
+
+	// Without a control socket nothing could reach the daemon
+	controlServer, err := control.Listen(socketPath, func(msg interface{}) { p.Send(msg) })
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to start daemon: %v\n", err)
+		return 1
+	}
+	go controlServer.Serve()
+	defer controlServer.Close()
+	a.serveHTTP(p)
+
+	// Survive the terminal or SSH session that started us going away
+	signal.Ignore(syscall.SIGHUP)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	go func() {
+		if _, ok := <-sigs; ok {
+			audio.KillAll()
+			p.Quit()
+		}
+	}()
+
+	fmt.Fprintf(os.Stderr, "zoneout daemon listening on %s\n", socketPath)
+	if a.api != nil {
+		fmt.Fprintf(os.Stderr, "HTTP API on http://%s/api/\n", a.api.Addr())
+	}
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error running daemon: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runAttach opens a dashboard on the running daemon
+func runAttach(configDir string) int {
+	socketPath := control.SocketPath(configDir)
+	if _, err := control.Send(socketPath, control.Request{Command: control.CommandStatus}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return runClient(configDir)
+}
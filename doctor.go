@@ -0,0 +1,135 @@
+package main
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"zoneout/audio"
+	"zoneout/calendar"
+	"zoneout/config"
+	"zoneout/models"
+	"zoneout/notify"
+)
+
+// runDoctor prints audio diagnostics: which player binaries were found,
+// whether the embedded assets could be cached and, with -play, the result
+// of a test playback
+func runDoctor(args []string, configDir string, assetsFS embed.FS) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	play := fs.Bool("play", false, "play the start chime to test audio output")
+	fs.Parse(args)
+
+	whitenoiseDir := filepath.Join(configDir, "whitenoise")
+	cacheDir := filepath.Join(configDir, "cache")
+	healthy := true
+
+	fmt.Printf("zoneout doctor (%s/%s)\n\n", runtime.GOOS, runtime.GOARCH)
+
+	// Player binaries
+	fmt.Println("Audio players:")
+	for _, b := range audio.DetectBackends() {
+		if b.Found {
+			fmt.Printf("  ✓ %-8s %s\n", b.Name, b.Path)
+		} else {
+			fmt.Printf("  ✗ %-8s not found on PATH\n", b.Name)
+		}
+	}
+
+	audioPlayer, err := audio.NewAudioPlayerWithEmbed(whitenoiseDir, cacheDir, assetsFS)
+	if err != nil {
+		fmt.Printf("\nFailed to initialize audio player: %v\n", err)
+		return 1
+	}
+	audioPlayer.SetVolume(config.NewConfig(configDir).GetVolume())
+
+	diag := audioPlayer.Diagnostics()
+	if diag.Player == "" {
+		healthy = false
+		fmt.Println("\n  No audio player found. Install ffmpeg (ffplay) or sox (play).")
+	} else {
+		fmt.Printf("\n  Using: %s\n", diag.Player)
+	}
+
+	// Embedded assets
+	fmt.Println("\nEmbedded assets:")
+	if diag.EmbeddedLoaded {
+		fmt.Printf("  ✓ whitenoise cached at %s\n", diag.EmbeddedPath)
+	} else {
+		healthy = false
+		fmt.Printf("  ✗ whitenoise could not be cached in %s\n", cacheDir)
+	}
+
+	pomodoro := models.NewPomodoro()
+	if err := pomodoro.SetAudioPlayerWithEmbed(audioPlayer, cacheDir, assetsFS); err != nil {
+		healthy = false
+		fmt.Printf("  ✗ sound effects: %v\n", err)
+	} else {
+		fmt.Println("  ✓ sound effects cached")
+	}
+
+	fmt.Printf("\nSounds available: %d (user directory: %s)\n", diag.AvailableSounds, whitenoiseDir)
+
+	// Playback test
+	fmt.Println("\nPlayback:")
+	if *play {
+		if err := pomodoro.PlayStartSoundSync(); err != nil {
+			healthy = false
+			fmt.Printf("  ✗ test playback failed: %v\n", err)
+		} else {
+			fmt.Println("  ✓ test playback succeeded")
+		}
+	} else {
+		fmt.Println("  skipped (run 'zoneout doctor -play' to test output)")
+	}
+
+	if err := audioPlayer.LastError(); err != nil {
+		fmt.Printf("  last error: %v\n", err)
+	}
+
+	desktop, osc, bell := config.NewConfig(configDir).GetNotifications()
+	notifier := notify.New(notify.Options{Desktop: desktop, Terminal: osc, Bell: bell}, notify.NewTerminal(os.Stdout), nil)
+	fmt.Printf("\nNotifications: %s\n", notifier.Backend())
+
+	// Task integrations only matter when enabled
+	taskwarrior, timewarrior := config.NewConfig(configDir).GetWarriors()
+	for _, integration := range []struct {
+		name, binary string
+		enabled      bool
+	}{{"Taskwarrior", "task", taskwarrior}, {"Timewarrior", "timew", timewarrior}} {
+		if !integration.enabled {
+			continue
+		}
+		if path, err := exec.LookPath(integration.binary); err == nil {
+			fmt.Printf("%s: ✓ %s\n", integration.name, path)
+		} else {
+			fmt.Printf("%s: ✗ %s not found on PATH, disabled\n", integration.name, integration.binary)
+		}
+	}
+
+	if path := config.NewConfig(configDir).GetCalendarICS(); path != "" {
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		cal := calendar.New(path)
+		if meetings, err := cal.Between(today, today.AddDate(0, 0, 1)); err == nil {
+			fmt.Printf("Calendar: ✓ %d meetings today in %s\n", len(meetings), path)
+			if err := cal.TakeError(); err != nil {
+				fmt.Printf("  ✗ %v\n", err)
+			}
+		} else {
+			fmt.Printf("Calendar: ✗ %v\n", err)
+		}
+	}
+
+	if !healthy {
+		fmt.Fprintln(os.Stderr, "\nProblems found, audio may be silent.")
+		return 1
+	}
+	fmt.Println("\nAll good.")
+	return 0
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"zoneout/config"
+	"zoneout/export"
+	"zoneout/orgclock"
+	"zoneout/stats"
+)
+
+// runExport writes the session history as calendar events, a CSV timesheet,
+// JSON or Org clocks
+func runExport(args []string, configDir string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "ics", "ics, csv, json or org")
+	since := fs.String("since", "all", "first day: YYYY-MM-DD, Nd for the last N days, today, week, last-week, month or all")
+	until := fs.String("until", "", "last day, YYYY-MM-DD (default today)")
+	output := fs.String("o", "", "write to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if !slices.Contains(export.Formats, *format) {
+		fmt.Fprintf(os.Stderr, "unknown format %q, use %s\n", *format, strings.Join(export.Formats, ", "))
+		return 2
+	}
+	start, end, err := parseRange(*since, *until, time.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	history, err := stats.NewStatsWithPath(configDir).History()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	var records []stats.SessionRecord
+	for _, rec := range history {
+		if stats.InRange(rec, start, end) {
+			records = append(records, rec)
+		}
+	}
+
+	if *format == "org" {
+		return exportOrg(records, *output, configDir)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	err = export.Write(out, *format, records)
+	if *output != "" {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "Exported %d sessions to %s\n", len(records), *output)
+	}
+	return 0
+}
+
+// exportOrg writes CLOCK lines under the configured org_heading, or each
+// session's task. An existing file is added to rather than replaced.
+func exportOrg(records []stats.SessionRecord, output, configDir string) int {
+	_, heading := config.NewConfig(configDir).GetOrgClock()
+	if output == "" {
+		if err := orgclock.Write(os.Stdout, heading, records); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	added, err := orgclock.NewFile(output, heading).Append(records...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Clocked %d sessions in %s", added, output)
+	if skipped := len(records) - added; skipped > 0 {
+		fmt.Fprintf(os.Stderr, ", %d were already there", skipped)
+	}
+	fmt.Fprintln(os.Stderr)
+	return 0
+}
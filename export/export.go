@@ -0,0 +1,176 @@
+// Package export writes the session history in formats other tools read:
+// iCalendar events for calendars, CSV timesheets, JSON and Org clocks.
+package export
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"zoneout/orgclock"
+	"zoneout/stats"
+)
+
+// Formats lists the supported export formats
+var Formats = []string{"ics", "csv", "json", "org"}
+
+// Write exports records to w in format
+func Write(w io.Writer, format string, records []stats.SessionRecord) error {
+	switch format {
+	case "ics":
+		return ICS(w, records)
+	case "csv":
+		return CSV(w, records)
+	case "json":
+		return JSON(w, records)
+	case "org":
+		return orgclock.Write(w, "", records)
+	}
+	return fmt.Errorf("unknown format %q, use %s", format, strings.Join(Formats, ", "))
+}
+
+// Summary names a session after its task, else its tags
+func Summary(rec stats.SessionRecord) string {
+	switch {
+	case rec.Task != "":
+		return rec.Task
+	case len(rec.Tags) > 0:
+		return strings.Join(rec.Tags, ", ")
+	}
+	return "Focus"
+}
+
+// ICS writes one VEVENT per focus session (RFC 5545). UIDs are derived from
+// the start time so exporting again updates the same events.
+func ICS(w io.Writer, records []stats.SessionRecord) error {
+	bw := bufio.NewWriter(w)
+	stamp := time.Now().UTC().Format(icsTime)
+
+	writeICSLine(bw, "BEGIN:VCALENDAR")
+	writeICSLine(bw, "VERSION:2.0")
+	writeICSLine(bw, "PRODID:-//zoneout//focus sessions//EN")
+	writeICSLine(bw, "CALSCALE:GREGORIAN")
+	for _, rec := range records {
+		start, end := span(rec)
+		writeICSLine(bw, "BEGIN:VEVENT")
+		writeICSLine(bw, "UID:"+uid(rec)+"@zoneout")
+		writeICSLine(bw, "DTSTAMP:"+stamp)
+		writeICSLine(bw, "DTSTART:"+start.UTC().Format(icsTime))
+		writeICSLine(bw, "DTEND:"+end.UTC().Format(icsTime))
+		writeICSLine(bw, "SUMMARY:"+escapeICS(Summary(rec)))
+		writeICSLine(bw, "DESCRIPTION:"+escapeICS(description(rec)))
+		if len(rec.Tags) > 0 {
+			tags := make([]string, len(rec.Tags))
+			for i, tag := range rec.Tags {
+				tags[i] = escapeICS(tag)
+			}
+			writeICSLine(bw, "CATEGORIES:"+strings.Join(tags, ","))
+		}
+		writeICSLine(bw, "TRANSP:OPAQUE")
+		writeICSLine(bw, "END:VEVENT")
+	}
+	writeICSLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+const icsTime = "20060102T150405Z"
+
+// writeICSLine ends the line with CRLF, folding it at 75 octets without
+// splitting UTF-8 sequences
+func writeICSLine(w *bufio.Writer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74 // Continuation lines start with a space
+	}
+	w.WriteString(line + "\r\n")
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeICS(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+func uid(rec stats.SessionRecord) string {
+	start, _ := span(rec)
+	sum := sha1.Sum([]byte(start.UTC().Format(time.RFC3339Nano)))
+	return fmt.Sprintf("%x", sum[:10])
+}
+
+// span is when the session ran; records without a start are placed before
+// their end
+func span(rec stats.SessionRecord) (start, end time.Time) {
+	start, end = rec.Start, rec.End
+	if start.IsZero() {
+		start = end.Add(-time.Duration(rec.Minutes) * time.Minute)
+	}
+	if end.IsZero() {
+		end = start.Add(time.Duration(rec.Minutes) * time.Minute)
+	}
+	return start, end
+}
+
+func description(rec stats.SessionRecord) string {
+	lines := []string{fmt.Sprintf("Focused for %d minutes", rec.Minutes)}
+	if rec.Task != "" && len(rec.Tags) > 0 {
+		lines = append(lines, "Tags: "+strings.Join(rec.Tags, ", "))
+	}
+	if internal, external := rec.InterruptionCounts(); internal+external > 0 {
+		lines = append(lines, fmt.Sprintf("Interruptions: %d internal, %d external", internal, external))
+	}
+	for _, in := range rec.Interruptions {
+		if in.Note != "" {
+			lines = append(lines, fmt.Sprintf("- %s %s: %s", in.At.Local().Format("15:04"), in.Kind, in.Note))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CSVHeader is the first row of a CSV timesheet
+var CSVHeader = []string{"date", "start", "end", "minutes", "hours", "task", "tags", "internal_interruptions", "external_interruptions"}
+
+// CSV writes a timesheet, one row per focus session in local time
+func CSV(w io.Writer, records []stats.SessionRecord) error {
+	cw := csv.NewWriter(w)
+	cw.Write(CSVHeader)
+	for _, rec := range records {
+		start, end := span(rec)
+		start, end = start.Local(), end.Local()
+		internal, external := rec.InterruptionCounts()
+		cw.Write([]string{
+			start.Format("2006-01-02"),
+			start.Format("15:04"),
+			end.Format("15:04"),
+			strconv.Itoa(rec.Minutes),
+			strconv.FormatFloat(float64(rec.Minutes)/60, 'f', 2, 64),
+			rec.Task,
+			strings.Join(rec.Tags, ";"),
+			strconv.Itoa(internal),
+			strconv.Itoa(external),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// JSON writes the records as a JSON array, the same fields as the history
+func JSON(w io.Writer, records []stats.SessionRecord) error {
+	if records == nil {
+		records = []stats.SessionRecord{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
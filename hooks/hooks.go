@@ -0,0 +1,192 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"zoneout/models"
+)
+
+// DefaultTimeout bounds a hook when the config doesn't set one
+const DefaultTimeout = 10 * time.Second
+
+// queueSize is how many hooks may wait for the one running
+const queueSize = 32
+
+// Runner runs user-defined shell commands on timer events. Hooks run one
+// at a time in event order on a background goroutine, each with a timeout,
+// so a slow or hung hook never blocks the timer.
+type Runner struct {
+	commands     map[models.Event]string
+	timeout      time.Duration
+	queue        chan models.PhaseEvent
+	done         chan struct{}
+	pendingError error // Failure not yet reported to the UI
+	mu           sync.Mutex
+}
+
+// NewRunner creates a runner for commands keyed by event name (see
+// models.Events). Unknown event names are reported as an error but the
+// known hooks are still installed.
+func NewRunner(commands map[string]string, timeout time.Duration) (*Runner, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	r := &Runner{
+		commands: make(map[models.Event]string),
+		timeout:  timeout,
+		queue:    make(chan models.PhaseEvent, queueSize),
+		done:     make(chan struct{}),
+	}
+	go r.work()
+
+	known := make(map[models.Event]bool, len(models.Events))
+	for _, event := range models.Events {
+		known[event] = true
+	}
+
+	var unknown []string
+	for name, command := range commands {
+		if !known[models.Event(name)] {
+			unknown = append(unknown, name)
+			continue
+		}
+		if strings.TrimSpace(command) != "" {
+			r.commands[models.Event(name)] = command
+		}
+	}
+	if len(unknown) > 0 {
+		return r, fmt.Errorf("unknown hook events: %s", strings.Join(unknown, ", "))
+	}
+	return r, nil
+}
+
+// Fire queues the hook for ev, if any. It has the models.Listener
+// signature so it can be registered on a Pomodoro.
+func (r *Runner) Fire(ev models.PhaseEvent) {
+	if _, ok := r.commands[ev.Event]; !ok {
+		return
+	}
+	select {
+	case r.queue <- ev:
+	default:
+		r.setError(fmt.Errorf("%s hook: too many hooks queued, dropped", ev.Event))
+	}
+}
+
+// Close waits for the queued hooks
+func (r *Runner) Close() {
+	close(r.queue)
+	<-r.done
+}
+
+func (r *Runner) work() {
+	defer close(r.done)
+	for ev := range r.queue {
+		if err := r.run(r.commands[ev.Event], ev); err != nil {
+			r.setError(fmt.Errorf("%s hook: %v", ev.Event, err))
+		}
+	}
+}
+
+func (r *Runner) setError(err error) {
+	r.mu.Lock()
+	r.pendingError = err
+	r.mu.Unlock()
+}
+
+func (r *Runner) run(command string, ev models.PhaseEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, command)
+	configureProcess(cmd)
+	cmd.Env = append(os.Environ(), Env(ev)...)
+	stdin, err := json.Marshal(payload(ev))
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = bytes.NewReader(stdin)
+
+	// Keep the last output for the error message; stdout is discarded so a
+	// chatty hook can't draw over the TUI
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	// Don't wait on background children still holding our pipes
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %v", r.timeout)
+	}
+	if err != nil {
+		if msg := lastLine(stderr.String()); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// TakeError returns a hook failure that has not been reported yet and
+// clears it, so the UI shows each failure once
+func (r *Runner) TakeError() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.pendingError
+	r.pendingError = nil
+	return err
+}
+
+// Env returns the environment variables describing ev
+func Env(ev models.PhaseEvent) []string {
+	return []string{
+		"ZONEOUT_EVENT=" + string(ev.Event),
+		"ZONEOUT_MODE=" + strings.ToLower(ev.Mode),
+		fmt.Sprintf("ZONEOUT_SESSION=%d", ev.Session),
+		fmt.Sprintf("ZONEOUT_TOTAL_SESSIONS=%d", ev.TotalSessions),
+		fmt.Sprintf("ZONEOUT_DURATION=%d", int(ev.Duration.Seconds())),
+		fmt.Sprintf("ZONEOUT_REMAINING=%d", int(ev.Remaining.Seconds())),
+		"ZONEOUT_TASK=" + ev.Task,
+		"ZONEOUT_BLOCK=" + ev.Block,
+	}
+}
+
+// hookPayload is the JSON written to a hook's stdin
+type hookPayload struct {
+	models.PhaseEvent
+	Mode             string `json:"mode"`
+	DurationSeconds  int    `json:"duration_seconds"`
+	RemainingSeconds int    `json:"remaining_seconds"`
+}
+
+func payload(ev models.PhaseEvent) hookPayload {
+	return hookPayload{
+		PhaseEvent:       ev,
+		Mode:             strings.ToLower(ev.Mode),
+		DurationSeconds:  int(ev.Duration.Seconds()),
+		RemainingSeconds: int(ev.Remaining.Seconds()),
+	}
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package hooks
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// configureProcess runs the hook in its own process group so a timeout
+// also kills whatever the shell started
+func configureProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// A negative PID signals the whole group
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,319 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"zoneout/control"
+	"zoneout/models"
+)
+
+// tickInterval is how often the event stream reports the timer state
+const tickInterval = time.Second
+
+// maxBodySize bounds request bodies, which only ever hold a small object
+const maxBodySize = 64 << 10
+
+// event is one server-sent event
+type event struct {
+	name string
+	data []byte
+}
+
+// Server is a localhost HTTP/JSON API for the timer. Commands go through
+// the same path as the control socket, and /api/events streams tick and
+// phase events as server-sent events.
+type Server struct {
+	addr           string
+	allowedOrigins map[string]bool
+	send           func(msg interface{})
+	httpServer     *http.Server
+	listener       net.Listener
+	subscribers    map[chan event]struct{}
+	stop           chan struct{}
+	mu             sync.Mutex
+}
+
+// New creates a server for addr, which must be a loopback address such as
+// 127.0.0.1:7878. Browser pages from allowedOrigins may use the API too.
+func New(addr string, allowedOrigins []string) (*Server, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTTP address %q: %w", addr, err)
+	}
+	if !isLoopback(host) {
+		return nil, fmt.Errorf("HTTP API must listen on localhost, not %q", host)
+	}
+
+	s := &Server{
+		addr:           addr,
+		allowedOrigins: make(map[string]bool),
+		subscribers:    make(map[chan event]struct{}),
+		stop:           make(chan struct{}),
+	}
+	for _, origin := range allowedOrigins {
+		s.allowedOrigins[origin] = true
+	}
+	return s, nil
+}
+
+// Start listens and serves in the background, handing commands to send
+func (s *Server) Start(send func(msg interface{})) error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to start HTTP API: %w", err)
+	}
+	s.send = send
+	s.listener = listener
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/status", s.handleStatus)
+	mux.HandleFunc("GET /api/sounds", s.handleSounds)
+	mux.HandleFunc("GET /api/stats", s.handleStats)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("POST /api/{command}", s.handleCommand)
+	mux.HandleFunc("OPTIONS /api/", s.handlePreflight)
+
+	s.httpServer = &http.Server{
+		Handler:           s.guard(mux),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go s.httpServer.Serve(listener)
+	go s.tick()
+	return nil
+}
+
+// Addr is the address the server listens on
+func (s *Server) Addr() string {
+	if s.listener != nil {
+		return s.listener.Addr().String()
+	}
+	return s.addr
+}
+
+// Close stops the server and ends all event streams
+func (s *Server) Close() error {
+	if s.httpServer == nil {
+		return nil
+	}
+	close(s.stop)
+	return s.httpServer.Close()
+}
+
+// Fire streams ev to every subscriber. It has the models.Listener
+// signature so it can be registered on a Pomodoro.
+func (s *Server) Fire(ev models.PhaseEvent) {
+	data, err := json.Marshal(ev.Payload())
+	if err != nil {
+		return
+	}
+	s.broadcast(event{name: "phase", data: data})
+}
+
+// guard rejects requests that don't come from this machine: a Host other
+// than localhost means DNS rebinding, and an unknown Origin is some other
+// website in the user's browser
+func (s *Server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if !isLoopback(host) {
+			http.Error(w, "forbidden host", http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if !s.allowedOrigins[origin] {
+				http.Error(w, "forbidden origin", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handlePreflight(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	resp := control.Dispatch(s.send, control.Request{Command: control.CommandStatus})
+	writeResult(w, resp, resp.Status)
+}
+
+func (s *Server) handleSounds(w http.ResponseWriter, r *http.Request) {
+	resp := control.Dispatch(s.send, control.Request{Command: control.CommandListSounds})
+	writeResult(w, resp, map[string][]string{"sounds": resp.Sounds})
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	resp := control.Dispatch(s.send, control.Request{Command: control.CommandStats})
+	writeResult(w, resp, resp.Stats)
+}
+
+// handleCommand runs POST /api/{command}. volume and sound take their
+// argument as a JSON body, e.g. {"volume": 0.4} or {"sound": "rain"}.
+func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request) {
+	req := control.Request{Command: r.PathValue("command")}
+	switch req.Command {
+	case control.CommandStart, control.CommandPause, control.CommandResume,
+		control.CommandToggle, control.CommandSkip, control.CommandReset:
+	case "volume", "sound":
+		var body control.Request
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, control.Response{Error: fmt.Sprintf("invalid request: %v", err)})
+			return
+		}
+		if req.Command == "volume" {
+			req.Command = control.CommandSetVolume
+			req.Volume = body.Volume
+		} else {
+			req.Command = control.CommandSelectSound
+			req.Sound = body.Sound
+		}
+	default:
+		writeJSON(w, http.StatusNotFound, control.Response{Error: fmt.Sprintf("unknown command %q", req.Command)})
+		return
+	}
+
+	resp := control.Dispatch(s.send, req)
+	status := http.StatusOK
+	if !resp.OK {
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, resp)
+}
+
+// handleEvents streams "tick" events with the status every second and
+// "phase" events as the timer changes phase
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	events := s.subscribe()
+	defer s.unsubscribe(events)
+
+	// Start with the current state rather than waiting for the next tick
+	if ev, ok := s.tickEvent(); ok {
+		writeEvent(w, ev)
+		flusher.Flush()
+	}
+	for {
+		select {
+		case ev := <-events:
+			if err := writeEvent(w, ev); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// tick broadcasts the status while anyone is listening
+func (s *Server) tick() {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			listening := len(s.subscribers) > 0
+			s.mu.Unlock()
+			if !listening {
+				continue
+			}
+			if ev, ok := s.tickEvent(); ok {
+				s.broadcast(ev)
+			}
+		}
+	}
+}
+
+func (s *Server) tickEvent() (event, bool) {
+	resp := control.Dispatch(s.send, control.Request{Command: control.CommandStatus})
+	if resp.Status == nil {
+		return event{}, false
+	}
+	data, err := json.Marshal(resp.Status)
+	if err != nil {
+		return event{}, false
+	}
+	return event{name: "tick", data: data}, true
+}
+
+func (s *Server) subscribe() chan event {
+	ch := make(chan event, 16)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+func (s *Server) unsubscribe(ch chan event) {
+	s.mu.Lock()
+	delete(s.subscribers, ch)
+	s.mu.Unlock()
+}
+
+func (s *Server) broadcast(ev event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			// A client that can't keep up misses events rather than
+			// holding up everyone else
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, ev event) error {
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.name, ev.data)
+	return err
+}
+
+// writeResult writes result, or the error from a failed command
+func writeResult(w http.ResponseWriter, resp control.Response, result interface{}) {
+	if !resp.OK {
+		writeJSON(w, http.StatusServiceUnavailable, control.Response{Error: resp.Error})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,78 @@
+package httpapi
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"zoneout/control"
+)
+
+const (
+	textContentType        = "text/plain; version=0.0.4; charset=utf-8"
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// handleMetrics serves the timer and session statistics for Prometheus, in
+// the OpenMetrics format when the scraper asks for it
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	statusResp := control.Dispatch(s.send, control.Request{Command: control.CommandStatus})
+	statsResp := control.Dispatch(s.send, control.Request{Command: control.CommandStats})
+	if statusResp.Status == nil || statsResp.Stats == nil {
+		http.Error(w, "zoneout did not respond", http.StatusServiceUnavailable)
+		return
+	}
+
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	if openMetrics {
+		w.Header().Set("Content-Type", openMetricsContentType)
+	} else {
+		w.Header().Set("Content-Type", textContentType)
+	}
+	writeMetrics(w, statusResp.Status, statsResp.Stats, openMetrics)
+}
+
+// writeMetrics writes the exposition for one scrape
+func writeMetrics(w io.Writer, status *control.Status, stats *control.Stats, openMetrics bool) {
+	counter := func(name, help string, value float64) {
+		family := name
+		if openMetrics {
+			// OpenMetrics names the family without the _total suffix
+			family = strings.TrimSuffix(name, "_total")
+		}
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %g\n", family, help, family, name, value)
+	}
+	gauge := func(name, help string, value float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, value)
+	}
+
+	counter("zoneout_sessions_completed_total", "Focus sessions completed.", float64(stats.TotalSessions))
+	counter("zoneout_focus_seconds_total", "Time spent in completed focus sessions.", float64(stats.TotalFocusMinutes*60))
+	counter("zoneout_pauses_total", "Times the timer was paused since zoneout started.", float64(status.Pauses))
+	counter("zoneout_skips_total", "Phases skipped since zoneout started.", float64(status.Skips))
+	gauge("zoneout_sessions_today", "Focus sessions completed today.", float64(stats.TodaySessions))
+
+	// One series per mode, 1 for the current one
+	fmt.Fprintf(w, "# HELP zoneout_mode Current timer mode.\n# TYPE zoneout_mode gauge\n")
+	for _, mode := range []string{"focus", "break", "idle"} {
+		fmt.Fprintf(w, "zoneout_mode{mode=%q} %d\n", mode, boolValue(status.Mode == mode))
+	}
+
+	gauge("zoneout_running", "Whether the timer is counting down.", float64(boolValue(status.Running)))
+	gauge("zoneout_paused", "Whether the timer is paused.", float64(boolValue(status.Paused)))
+	gauge("zoneout_remaining_seconds", "Time left in the current phase.", float64(status.RemainingSeconds))
+	gauge("zoneout_phase_seconds", "Length of the current phase.", float64(status.TotalSeconds))
+	gauge("zoneout_session", "Current session number, 0 when idle.", float64(status.Session))
+
+	if openMetrics {
+		fmt.Fprintln(w, "# EOF")
+	}
+}
+
+func boolValue(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"zoneout/importer"
+	"zoneout/stats"
+)
+
+// runImport merges sessions exported by other apps into the history
+func runImport(args []string, configDir string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "csv, toggl or json (default from the file extension)")
+	columns := fs.String("map", "", "CSV columns per field, e.g. start=Begin,task=Description,tags=Project+Tags")
+	layout := fs.String("layout", "", "Go time layout of CSV dates and times, e.g. \"02.01.2006 15:04\"")
+	dryRun := fs.Bool("dry-run", false, "show what would be imported without changing the history")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: zoneout import [flags] FILE... (- for stdin)")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	opts := importer.Options{Layout: *layout}
+	if *columns != "" {
+		opts.Columns = make(map[string]string)
+		for _, pair := range strings.Split(*columns, ",") {
+			field, column, ok := strings.Cut(pair, "=")
+			field = strings.TrimSpace(field)
+			if !ok || !importer.ValidField(field) {
+				fmt.Fprintf(os.Stderr, "invalid -map entry %q, fields are date, start, end_date, end, minutes, duration, task and tags\n", pair)
+				return 2
+			}
+			opts.Columns[field] = strings.TrimSpace(column)
+		}
+	}
+
+	var records []stats.SessionRecord
+	for _, path := range fs.Args() {
+		name := *format
+		if name == "" {
+			name = "csv"
+			if strings.EqualFold(filepath.Ext(path), ".json") || strings.EqualFold(filepath.Ext(path), ".jsonl") {
+				name = "json"
+			}
+		}
+		parser, err := importer.New(name, opts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		parsed, err := parseFile(parser, path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			return 1
+		}
+		records = append(records, parsed...)
+	}
+
+	if *dryRun {
+		for _, rec := range records {
+			fmt.Printf("%s  %3dm  %s\n", rec.Start.Local().Format("2006-01-02 15:04"), rec.Minutes, strings.Join(append([]string{rec.Task}, rec.Tags...), " "))
+		}
+		fmt.Printf("%d sessions read, nothing imported\n", len(records))
+		return 0
+	}
+
+	added, err := stats.NewStatsWithPath(configDir).Import(records)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("Imported %d sessions", added)
+	if skipped := len(records) - added; skipped > 0 {
+		fmt.Printf(", skipped %d already in the history", skipped)
+	}
+	fmt.Println()
+	return 0
+}
+
+func parseFile(parser importer.Parser, path string) ([]stats.SessionRecord, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return parser.Parse(r)
+}
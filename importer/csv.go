@@ -0,0 +1,261 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"zoneout/stats"
+)
+
+// CSV fields a column can be mapped to
+const (
+	FieldDate     = "date"     // Day the session started, when start is only a time
+	FieldStart    = "start"    // Start time, or date and time
+	FieldEndDate  = "end_date" // Day the session ended, default the start day
+	FieldEnd      = "end"      // End time, or date and time
+	FieldMinutes  = "minutes"  // Focus minutes
+	FieldDuration = "duration" // Focus time as 1:25:00, 1:25 or 85m
+	FieldTask     = "task"
+	FieldTags     = "tags" // Several columns may be joined with +
+)
+
+var csvFields = []string{FieldDate, FieldStart, FieldEndDate, FieldEnd, FieldMinutes, FieldDuration, FieldTask, FieldTags}
+
+// CSV reads a spreadsheet with one session per row. Columns are found by
+// header name, by default the field names, which is what `zoneout export
+// --format csv` writes. Only a start is required; the length comes from the
+// minutes, the duration or the end, in that order.
+type CSV struct {
+	columns  map[string][]string
+	layout   string
+	location *time.Location
+}
+
+// NewCSV returns a CSV parser, with opts.Columns overriding the default
+// column of each field
+func NewCSV(opts Options) *CSV {
+	c := &CSV{
+		columns:  make(map[string][]string),
+		layout:   opts.Layout,
+		location: opts.Location,
+	}
+	if c.location == nil {
+		c.location = time.Local
+	}
+	for _, field := range csvFields {
+		c.columns[field] = []string{field}
+	}
+	for field, column := range opts.Columns {
+		c.columns[field] = strings.Split(column, "+")
+	}
+	return c
+}
+
+// ValidField reports whether field can be mapped to a column
+func ValidField(field string) bool {
+	for _, f := range csvFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CSV) Parse(r io.Reader) ([]stats.SessionRecord, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	index := make(map[string]int)
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff") // Spreadsheet apps like a BOM
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := c.column(index, FieldStart); !ok {
+		return nil, fmt.Errorf("no %q column for the start time, map one with start=COLUMN", strings.Join(c.columns[FieldStart], "+"))
+	}
+
+	var records []stats.SessionRecord
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		rec, err := c.record(index, row)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if rec.Minutes > 0 {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+// column is the index of the first column mapped to field
+func (c *CSV) column(index map[string]int, field string) (int, bool) {
+	for _, name := range c.columns[field] {
+		if i, ok := index[strings.ToLower(strings.TrimSpace(name))]; ok {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// values are the non-empty cells of the columns mapped to field
+func (c *CSV) values(index map[string]int, row []string, field string) []string {
+	var values []string
+	for _, name := range c.columns[field] {
+		i, ok := index[strings.ToLower(strings.TrimSpace(name))]
+		if ok && i < len(row) && strings.TrimSpace(row[i]) != "" {
+			values = append(values, strings.TrimSpace(row[i]))
+		}
+	}
+	return values
+}
+
+func (c *CSV) value(index map[string]int, row []string, field string) string {
+	if values := c.values(index, row, field); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c *CSV) record(index map[string]int, row []string) (stats.SessionRecord, error) {
+	var rec stats.SessionRecord
+
+	date := c.value(index, row, FieldDate)
+	start, err := c.parseTime(date, c.value(index, row, FieldStart))
+	if err != nil {
+		return rec, fmt.Errorf("start: %w", err)
+	}
+	rec.Start = start
+
+	if end := c.value(index, row, FieldEnd); end != "" {
+		endDate := c.value(index, row, FieldEndDate)
+		if endDate == "" {
+			endDate = date
+		}
+		if rec.End, err = c.parseTime(endDate, end); err != nil {
+			return rec, fmt.Errorf("end: %w", err)
+		}
+		if rec.End.Before(rec.Start) { // Past midnight
+			rec.End = rec.End.AddDate(0, 0, 1)
+		}
+	}
+
+	switch minutes, duration := c.value(index, row, FieldMinutes), c.value(index, row, FieldDuration); {
+	case minutes != "":
+		n, err := strconv.ParseFloat(minutes, 64)
+		if err != nil {
+			return rec, fmt.Errorf("invalid minutes %q", minutes)
+		}
+		rec.Minutes = int(n + 0.5)
+	case duration != "":
+		d, err := parseDuration(duration)
+		if err != nil {
+			return rec, err
+		}
+		rec.Minutes = int(d.Round(time.Minute).Minutes())
+	case !rec.End.IsZero():
+		rec.Minutes = int(rec.End.Sub(rec.Start).Round(time.Minute).Minutes())
+	default:
+		return rec, fmt.Errorf("no end, minutes or duration")
+	}
+	if rec.End.IsZero() {
+		rec.End = rec.Start.Add(time.Duration(rec.Minutes) * time.Minute)
+	}
+
+	rec.Task = c.value(index, row, FieldTask)
+	for _, cell := range c.values(index, row, FieldTags) {
+		for _, tag := range strings.FieldsFunc(cell, func(r rune) bool { return r == ',' || r == ';' }) {
+			if tag = strings.TrimSpace(tag); tag != "" && !contains(rec.Tags, tag) {
+				rec.Tags = append(rec.Tags, tag)
+			}
+		}
+	}
+	return rec, nil
+}
+
+// timeLayouts are tried in order when no layout is given
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"01/02/2006 15:04:05",
+	"01/02/2006 15:04",
+	"01/02/2006 3:04 PM",
+	"Jan 2, 2006 3:04 PM",
+	"Jan 2, 2006, 3:04:05 PM",
+}
+
+// parseTime reads value, prefixed with date when given
+func (c *CSV) parseTime(date, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("missing")
+	}
+	if date != "" {
+		value = date + " " + value
+	}
+	if c.layout != "" {
+		return time.ParseInLocation(c.layout, value, c.location)
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, c.location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown time format %q, set the layout", value)
+}
+
+// parseDuration reads H:MM:SS, H:MM, a Go duration such as 25m or a
+// number of minutes
+func parseDuration(s string) (time.Duration, error) {
+	if parts := strings.Split(s, ":"); len(parts) == 2 || len(parts) == 3 {
+		var d time.Duration
+		units := []time.Duration{time.Hour, time.Minute, time.Second}
+		for i, part := range parts {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			d += time.Duration(n) * units[i]
+		}
+		return d, nil
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(n * float64(time.Minute)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+// Package importer reads focus sessions exported by other pomodoro and time
+// tracking apps, so they can be merged into the zoneout history.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"zoneout/stats"
+)
+
+// Parser reads sessions in one export format
+type Parser interface {
+	Parse(r io.Reader) ([]stats.SessionRecord, error)
+}
+
+// Options tune the parsers; the zero value uses the defaults
+type Options struct {
+	Columns  map[string]string // CSV field to column name(s), see CSV
+	Layout   string            // Time layout for CSV dates and times, guessed when empty
+	Location *time.Location    // For times without a zone, default local time
+}
+
+// parsers builds a Parser per format name
+var parsers = map[string]func(Options) Parser{
+	"csv":   func(opts Options) Parser { return NewCSV(opts) },
+	"toggl": func(opts Options) Parser { return NewToggl(opts) },
+	"json":  func(Options) Parser { return JSON{} },
+}
+
+// Formats lists the format names New accepts
+func Formats() []string {
+	names := make([]string, 0, len(parsers))
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New returns the parser for format
+func New(format string, opts Options) (Parser, error) {
+	build, ok := parsers[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q, use %s", format, strings.Join(Formats(), ", "))
+	}
+	return build(opts), nil
+}
@@ -0,0 +1,242 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"zoneout/stats"
+)
+
+// parseFixture reads testdata/name with the parser for format
+func parseFixture(t *testing.T, format, name string, opts Options) []stats.SessionRecord {
+	t.Helper()
+	parser, err := New(format, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := parser.Parse(f)
+	if err != nil {
+		t.Fatalf("Parse %s: %v", name, err)
+	}
+	return records
+}
+
+func at(day, clock string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", day+" "+clock, time.UTC)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestToggl(t *testing.T) {
+	got := parseFixture(t, "toggl", "toggl.csv", Options{Location: time.UTC})
+	want := []stats.SessionRecord{
+		{
+			Start:   at("2026-10-05", "09:00:00"),
+			End:     at("2026-10-05", "09:25:00"),
+			Minutes: 25,
+			Task:    "Write landing copy",
+			Tags:    []string{"Acme", "Website", "focus", "writing"},
+		},
+		{
+			// Past midnight, named after the Toggl task when there's no description
+			Start:   at("2026-10-05", "23:50:00"),
+			End:     at("2026-10-06", "00:40:00"),
+			Minutes: 50,
+			Task:    "Inbox",
+			Tags:    []string{"Admin"},
+		},
+		{
+			// The duration is rounded to the minute, the seconds kept in the end
+			Start:   at("2026-10-06", "10:00:00"),
+			End:     at("2026-10-06", "11:05:30"),
+			Minutes: 66,
+			Task:    "Review",
+			Tags:    []string{"Acme", "Website", "writing"},
+		},
+		// The 20 second entry rounds to nothing and is left out
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("records =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestCSVColumnMapping(t *testing.T) {
+	opts := Options{
+		Columns: map[string]string{
+			FieldDate:     "Date",
+			FieldStart:    "begin", // Header names ignore case
+			FieldDuration: "Length",
+			FieldTask:     "Note",
+			FieldTags:     "Project+Client",
+		},
+		Location: time.UTC,
+	}
+	got := parseFixture(t, "csv", "custom.csv", opts)
+	want := []stats.SessionRecord{
+		{
+			Start:   at("2026-10-07", "14:00:00"),
+			End:     at("2026-10-07", "14:25:00"),
+			Minutes: 25,
+			Task:    "Hero section",
+			Tags:    []string{"Website", "Acme"},
+		},
+		{
+			Start:   at("2026-10-07", "14:30:00"),
+			End:     at("2026-10-07", "15:20:00"),
+			Minutes: 50,
+			Tags:    []string{"Website", "Design"},
+		},
+		{
+			Start:   at("2026-10-07", "16:00:00"),
+			End:     at("2026-10-07", "16:15:00"),
+			Minutes: 15,
+			Task:    "Inbox",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("records =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestCSVLayout(t *testing.T) {
+	input := "start,minutes\n07.10.2026 14:00,25\n"
+	parser := NewCSV(Options{Layout: "02.01.2006 15:04", Location: time.UTC})
+	got, err := parser.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !got[0].Start.Equal(at("2026-10-07", "14:00:00")) || got[0].Minutes != 25 {
+		t.Errorf("records = %+v", got)
+	}
+}
+
+func TestCSVErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"begin,minutes\n2026-10-07 14:00,25\n", `no "start" column`},
+		{"start,minutes\nyesterday,25\n", "line 2: start: unknown time format"},
+		{"start,minutes\n2026-10-07 14:00,lots\n", `line 2: invalid minutes "lots"`},
+		{"start\n2026-10-07 14:00\n", "line 2: no end, minutes or duration"},
+		{"start,duration\n2026-10-07 14:00,1:xx\n", `line 2: invalid duration "1:xx"`},
+	}
+	for _, tt := range tests {
+		_, err := NewCSV(Options{Location: time.UTC}).Parse(strings.NewReader(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: err = %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	array := parseFixture(t, "json", "sessions.json", Options{})
+	lines := parseFixture(t, "json", "sessions.jsonl", Options{})
+	if len(array) != 2 {
+		t.Fatalf("%d records, want 2", len(array))
+	}
+	if !reflect.DeepEqual(array, lines) {
+		t.Errorf("array and history file differ:\n%+v\n%+v", array, lines)
+	}
+	if internal, external := array[1].InterruptionCounts(); internal != 0 || external != 1 || array[1].TaskID != "abc" {
+		t.Errorf("record = %+v", array[1])
+	}
+}
+
+func TestImportSkipsDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	records := parseFixture(t, "toggl", "toggl.csv", Options{Location: time.UTC})
+
+	s := stats.NewStatsWithPath(dir)
+	if err := s.RecordSession(stats.SessionRecord{
+		// Already tracked by zoneout, a few seconds off the Toggl entry
+		Start:   at("2026-10-05", "09:00:40"),
+		End:     at("2026-10-05", "09:25:40"),
+		Minutes: 25,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := s.Import(records)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if added != 2 {
+		t.Errorf("added %d sessions, want 2", added)
+	}
+	if added, err := s.Import(records); err != nil || added != 0 {
+		t.Errorf("importing again added %d sessions, %v", added, err)
+	}
+
+	history, err := s.History()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("%d sessions in the history, want 3", len(history))
+	}
+	for i := 1; i < len(history); i++ {
+		if history[i].Start.Before(history[i-1].Start) {
+			t.Errorf("history not in order: %v before %v", history[i-1].Start, history[i].Start)
+		}
+	}
+
+	s = stats.NewStatsWithPath(dir)
+	if s.GetTotalSessions() != 3 || s.GetTotalFocusMinutes() != 25+50+66 {
+		t.Errorf("counters = %d sessions, %d minutes", s.GetTotalSessions(), s.GetTotalFocusMinutes())
+	}
+}
+
+func TestImportRecomputesCounters(t *testing.T) {
+	dir := t.TempDir()
+	// Nine sessions counted before the history was kept
+	counters := `{"total_sessions": 10, "total_focus_minutes": 250}`
+	if err := os.WriteFile(filepath.Join(dir, ".zoneout_stats"), []byte(counters), 0644); err != nil {
+		t.Fatal(err)
+	}
+	history := `{"start":"2026-10-01T09:00:00Z","end":"2026-10-01T09:25:00Z","minutes":25}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, ".zoneout_sessions"), []byte(history), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := stats.NewStatsWithPath(dir)
+	now := time.Now().Truncate(time.Minute)
+	if _, err := s.Import([]stats.SessionRecord{
+		{Start: now.Add(-25 * time.Minute), End: now, Minutes: 25},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s = stats.NewStatsWithPath(dir)
+	if s.GetTotalSessions() != 11 || s.GetTotalFocusMinutes() != 275 {
+		t.Errorf("counters = %d sessions, %d minutes, want 11 and 275", s.GetTotalSessions(), s.GetTotalFocusMinutes())
+	}
+	if today := s.GetTodaySessions(); today != 1 {
+		t.Errorf("today = %d sessions, want 1", today)
+	}
+
+	// A history with more than the counters replaces them
+	if err := os.WriteFile(filepath.Join(dir, ".zoneout_stats"), []byte(`{"total_sessions": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s = stats.NewStatsWithPath(dir)
+	if _, err := s.Import([]stats.SessionRecord{
+		{Start: at("2026-10-02", "09:00:00"), End: at("2026-10-02", "09:25:00"), Minutes: 25},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s = stats.NewStatsWithPath(dir)
+	if s.GetTotalSessions() != 3 || s.GetTotalFocusMinutes() != 75 {
+		t.Errorf("counters = %d sessions, %d minutes, want 3 and 75", s.GetTotalSessions(), s.GetTotalFocusMinutes())
+	}
+}
@@ -0,0 +1,45 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"zoneout/stats"
+)
+
+// JSON reads `zoneout export --format json`, an array of sessions, or a
+// session history file with one session per line
+type JSON struct{}
+
+func (JSON) Parse(r io.Reader) ([]stats.SessionRecord, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON: %w", err)
+	}
+	data = bytes.TrimSpace(data)
+
+	var records []stats.SessionRecord
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		return records, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec stats.SessionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
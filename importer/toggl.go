@@ -0,0 +1,27 @@
+package importer
+
+// togglColumns are the columns of a Toggl Track detailed report exported as
+// CSV. The client, project and tags all become zoneout tags.
+var togglColumns = map[string]string{
+	FieldDate:     "Start date",
+	FieldStart:    "Start time",
+	FieldEndDate:  "End date",
+	FieldEnd:      "End time",
+	FieldDuration: "Duration",
+	FieldTask:     "Description+Task",
+	FieldTags:     "Client+Project+Tags",
+}
+
+// NewToggl returns a CSV parser for Toggl Track exports; opts.Columns can
+// still override a column
+func NewToggl(opts Options) *CSV {
+	columns := make(map[string]string, len(togglColumns))
+	for field, column := range togglColumns {
+		columns[field] = column
+	}
+	for field, column := range opts.Columns {
+		columns[field] = column
+	}
+	opts.Columns = columns
+	return NewCSV(opts)
+}
@@ -5,14 +5,26 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"zoneout/audio"
+	"zoneout/calendar"
 	"zoneout/config"
+	"zoneout/control"
+	"zoneout/hooks"
+	"zoneout/httpapi"
 	"zoneout/models"
+	"zoneout/notify"
+	"zoneout/orgclock"
 	"zoneout/stats"
+	"zoneout/statusbar"
+	"zoneout/tasks"
+	"zoneout/timew"
 	"zoneout/ui"
+	"zoneout/webhooks"
 )
 
 //go:embed sounds/* motd/* whitenoise/*
@@ -25,28 +37,159 @@ func main() {
 		log.Fatalf("Failed to get home directory: %v", err)
 	}
 
-	// Create config directory for zoneout
+	// Create config directory for zoneout. ZONEOUT_DIR overrides it, e.g.
+	// to run several independent instances on one machine.
 	configDir := filepath.Join(homeDir, ".zoneout")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if dir := os.Getenv("ZONEOUT_DIR"); dir != "" {
+		configDir = dir
+	}
+	if err := os.MkdirAll(configDir, 0700); err != nil {
 		log.Fatalf("Failed to create config directory: %v", err)
 	}
 
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "doctor":
+			os.Exit(runDoctor(os.Args[2:], configDir, assetsFS))
+		case "sleep":
+			os.Exit(runSleep(os.Args[2:], configDir, assetsFS))
+		case "ctl":
+			os.Exit(runCtl(os.Args[2:], configDir))
+		case "status":
+			os.Exit(runStatus(os.Args[2:], configDir))
+		case "daemon":
+			os.Exit(runDaemon(os.Args[2:], configDir))
+		case "attach":
+			os.Exit(runAttach(configDir))
+		case "host":
+			os.Exit(runHost(os.Args[2:], configDir))
+		case "join":
+			os.Exit(runJoin(os.Args[2:], configDir))
+		case "report":
+			os.Exit(runReport(os.Args[2:], configDir))
+		case "export":
+			os.Exit(runExport(os.Args[2:], configDir))
+		case "import":
+			os.Exit(runImport(os.Args[2:], configDir))
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown command: %s\n", os.Args[1])
+			fmt.Fprintln(os.Stderr, "Usage: zoneout [doctor|sleep|ctl|status|daemon|attach|host|join|report|export|import]")
+			os.Exit(2)
+		}
+	}
+
+	// runTUI returns instead of exiting so its deferred cleanup always runs
+	os.Exit(runTUI(configDir))
+}
+
+// runTUI runs the dashboard. If another instance (a daemon or another TUI)
+// is already running it attaches to that instead of starting a timer.
+func runTUI(configDir string) int {
+	socketPath := control.SocketPath(configDir)
+	if _, err := control.Send(socketPath, control.Request{Command: control.CommandStatus}); err == nil {
+		return runClient(configDir)
+	}
+	return runStandalone(configDir, nil)
+}
+
+// runStandalone runs a timer instance with its own dashboard. setup, if
+// not nil, is called with the program before it starts; an error from it
+// is fatal.
+func runStandalone(configDir string, setup func(a *app, p *tea.Program) error) int {
+	socketPath := control.SocketPath(configDir)
+
+	// Kill any player still running when we leave, whatever the reason
+	defer audio.KillAll()
+
+	terminal := notify.NewTerminal(os.Stdout)
+	a, err := newApp(configDir, terminal)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer a.Close()
+
+	// Create the Bubble Tea program. Signals are handled here rather than by
+	// Bubble Tea so SIGHUP (terminal closed) also shuts the players down.
+	p := tea.NewProgram(a.model, tea.WithAltScreen(), tea.WithoutSignalHandler(), tea.WithOutput(terminal))
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+	go func() {
+		if _, ok := <-sigs; ok {
+			// Silence audio immediately, then let the program unwind
+			audio.KillAll()
+			p.Quit()
+		}
+	}()
+
+	// Accept commands from scripts and other tools on the control socket
+	controlServer, err := control.Listen(socketPath, func(msg interface{}) { p.Send(msg) })
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Remote control disabled: %v\n", err)
+	} else {
+		go controlServer.Serve()
+		defer controlServer.Close()
+	}
+	a.serveHTTP(p)
+
+	if setup != nil {
+		if err := setup(a, p); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runClient attaches a dashboard to the instance running in configDir
+func runClient(configDir string) int {
+	model := ui.NewAttachedModel(control.SocketPath(configDir), stats.NewStatsWithPath(configDir))
+	p := tea.NewProgram(model, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// app is everything a timer instance owns: audio, config, MOTD, stats and
+// the Pomodoro, wired into a ui.Model, plus the services reporting on it
+type app struct {
+	model   *ui.Model
+	api     *httpapi.Server // nil unless http_addr is configured
+	closers []func()
+}
+
+// newApp sets up a timer instance. terminal receives terminal
+// notifications, nil when there is none. Close must be called once the
+// model is no longer running.
+func newApp(configDir string, terminal *notify.Terminal) (*app, error) {
 	// Set up directory paths
 	whitenoiseDir := filepath.Join(configDir, "whitenoise")
 	motdDir := filepath.Join(configDir, "motd")
+	cacheDir := filepath.Join(configDir, "cache")
 
 	// Create white noise directory if it doesn't exist
 	if err := os.MkdirAll(whitenoiseDir, 0755); err != nil {
-		log.Fatalf("Failed to create whitenoise directory: %v", err)
+		return nil, fmt.Errorf("failed to create whitenoise directory: %w", err)
 	}
 
+	// Remove temp files left behind by runs that crashed or were killed
+	audio.RemoveStaleTempFiles()
+
 	// Initialize audio player with embedded whitenoise + user files
-	audioPlayer, err := audio.NewAudioPlayerWithEmbed(whitenoiseDir, assetsFS)
+	audioPlayer, err := audio.NewAudioPlayerWithEmbed(whitenoiseDir, cacheDir, assetsFS)
 	if err != nil {
-		log.Fatalf("Failed to initialize audio player: %v", err)
+		return nil, fmt.Errorf("failed to initialize audio player: %w", err)
 	}
-	defer audioPlayer.Stop()
-	defer audioPlayer.Cleanup()
 
 	// Initialize config
 	appConfig := config.NewConfig(configDir)
@@ -56,7 +199,7 @@ func main() {
 
 	// Create motd directory if it doesn't exist (for user-provided messages)
 	if err := os.MkdirAll(motdDir, 0755); err != nil {
-		log.Fatalf("Failed to create motd directory: %v", err)
+		return nil, fmt.Errorf("failed to create motd directory: %w", err)
 	}
 
 	// Initialize MOTD from embedded + user files
@@ -72,20 +215,135 @@ func main() {
 
 	// Initialize Pomodoro state
 	pomodoroState := models.NewPomodoro()
+	if text := appConfig.GetPlan(); text != "" {
+		if plan, err := models.ParsePlan(text); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Invalid plan in config, using the default cycle: %v\n", err)
+		} else {
+			pomodoroState.SetPlan(plan)
+		}
+	}
 
 	// Set up transition sound effects from embedded assets
-	if err := pomodoroState.SetAudioPlayerWithEmbed(audioPlayer, assetsFS); err != nil {
+	if err := pomodoroState.SetAudioPlayerWithEmbed(audioPlayer, cacheDir, assetsFS); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: Failed to load sound effects: %v\n", err)
 	}
-	defer pomodoroState.Cleanup()
+
+	// Tasks come from Taskwarrior or todo.txt if configured, otherwise
+	// from the built-in list
+	useTaskwarrior, useTimewarrior := appConfig.GetWarriors()
+	var taskSource tasks.Source
+	if useTaskwarrior {
+		if tw, err := tasks.NewTaskwarrior(filepath.Join(configDir, "taskwarrior_active")); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, using the built-in task list\n", err)
+		} else {
+			taskSource = tw
+		}
+	}
+	if todoPath := appConfig.GetTodoTxt(); taskSource == nil && todoPath != "" {
+		taskSource = tasks.NewTodoTxt(todoPath, filepath.Join(configDir, "todo_active"))
+	}
+	if taskSource == nil {
+		taskSource = tasks.NewStore(configDir)
+	}
+
+	// Track focus sessions in Timewarrior
+	var tracker *timew.Tracker
+	if useTimewarrior {
+		if tracker, err = timew.New(taskSource.Active); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			pomodoroState.AddListener(tracker.Fire)
+		}
+	}
+
+	// Run the user's hook commands on timer events
+	hookRunner, err := hooks.NewRunner(appConfig.GetHooks(), appConfig.GetHookTimeout())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	pomodoroState.AddListener(hookRunner.Fire)
+
+	// Deliver events to HTTP webhooks, queued on disk while offline
+	dispatcher, err := webhooks.New(configDir, appConfig.GetWebhooks())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	dispatcher.Start()
+	pomodoroState.AddListener(dispatcher.Fire)
+
+	// Tell the user about phase changes while the terminal is hidden
+	desktop, osc, bell := appConfig.GetNotifications()
+	notifier := notify.New(notify.Options{Desktop: desktop, Terminal: osc, Bell: bell}, terminal, motdManager.GetMessage)
+	pomodoroState.AddListener(notifier.Fire)
+
+	// Optional HTTP API for browser and Stream Deck companions
+	var api *httpapi.Server
+	if addr, origins := appConfig.GetHTTP(); addr != "" {
+		if api, err = httpapi.New(addr, origins); err != nil {
+			return nil, err
+		}
+		pomodoroState.AddListener(api.Fire)
+	}
 
 	// Create the main model
 	mainModel := ui.NewModel(pomodoroState, audioPlayer, appStats, appConfig, motdManager)
+	mainModel.AddErrorSource(hookRunner)
+	mainModel.AddErrorSource(dispatcher)
+	mainModel.AddErrorSource(notifier)
 
-	// Create and run the Bubble Tea program
-	p := tea.NewProgram(mainModel, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
-		os.Exit(1)
+	// Focus sessions are attributed to the task picked in the task panel
+	mainModel.SetTaskSource(taskSource)
+	if src, ok := taskSource.(ui.ErrorSource); ok {
+		mainModel.AddErrorSource(src)
+	}
+	if tracker != nil {
+		mainModel.AddErrorSource(tracker)
+	}
+
+	// End focus phases before the meetings in an exported calendar
+	if path := appConfig.GetCalendarICS(); path != "" {
+		cal := calendar.New(path)
+		mainModel.SetCalendar(cal)
+		mainModel.AddErrorSource(cal)
+	}
+
+	// Clock focus sessions in Org mode for Emacs clock tables
+	if orgFile, orgHeading := appConfig.GetOrgClock(); orgFile != "" {
+		mainModel.SetOrgClock(orgclock.NewFile(orgFile, orgHeading))
+	}
+
+	// Keep a status file up to date for tmux, polybar and waybar
+	statusFile := statusbar.FilePath(configDir)
+	mainModel.SetStatusFile(statusFile)
+
+	a := &app{model: mainModel, api: api}
+	if tracker != nil {
+		a.closers = append(a.closers, tracker.Close)
+	}
+	if closer, ok := taskSource.(interface{ Close() }); ok {
+		a.closers = append(a.closers, closer.Close)
+	}
+	a.closers = append(a.closers, hookRunner.Close, dispatcher.Close, func() { os.Remove(statusFile) }, audioPlayer.Stop)
+	return a, nil
+}
+
+// serveHTTP starts the HTTP API, if configured, handing commands to p. A
+// failure only disables the API.
+func (a *app) serveHTTP(p *tea.Program) {
+	if a.api == nil {
+		return
+	}
+	if err := a.api.Start(func(msg interface{}) { p.Send(msg) }); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		a.api = nil
+		return
+	}
+	a.closers = append([]func(){func() { a.api.Close() }}, a.closers...)
+}
+
+// Close stops everything newApp started
+func (a *app) Close() {
+	for _, closeFn := range a.closers {
+		closeFn()
 	}
 }
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Event names a timer transition. The values double as the hook names in
+// the config file, so they must not change.
+type Event string
+
+const (
+	EventFocusStart    Event = "focus_start"
+	EventFocusEnd      Event = "focus_end"
+	EventBreakStart    Event = "break_start"
+	EventBreakEnd      Event = "break_end"
+	EventCycleComplete Event = "cycle_complete"
+	EventPause         Event = "pause"
+	EventResume        Event = "resume"
+	EventReset         Event = "reset" // The cycle was ended early
+)
+
+// Events lists every event the Pomodoro emits
+var Events = []Event{
+	EventFocusStart, EventFocusEnd, EventBreakStart, EventBreakEnd,
+	EventCycleComplete, EventPause, EventResume, EventReset,
+}
+
+// PhaseEvent describes a transition and the phase it concerns: the phase
+// starting for *_start and resume, the phase ending for *_end, pause
+// and reset
+type PhaseEvent struct {
+	Event         Event         `json:"event"`
+	Mode          string        `json:"mode"`
+	Session       int           `json:"session"`
+	TotalSessions int           `json:"total_sessions"`
+	Block         string        `json:"block,omitempty"` // Label of the planned block
+	Duration      time.Duration `json:"-"`
+	Remaining     time.Duration `json:"-"`
+	Task          string        `json:"task,omitempty"`
+	Time          time.Time     `json:"time"`
+}
+
+// Listener is called synchronously on every transition, it must not block
+type Listener func(PhaseEvent)
+
+// AddListener registers fn to be called on every timer transition
+func (p *Pomodoro) AddListener(fn Listener) {
+	p.listeners = append(p.listeners, fn)
+}
+
+func (p *Pomodoro) emit(event Event) {
+	if len(p.listeners) == 0 {
+		return
+	}
+	ev := PhaseEvent{
+		Event:         event,
+		Mode:          p.GetModeString(),
+		Session:       p.CurrentSession,
+		TotalSessions: p.TotalSessions,
+		Block:         p.BlockLabel,
+		Duration:      p.TotalTime,
+		Remaining:     p.RemainingTime,
+		Task:          p.Task,
+		Time:          time.Now(),
+	}
+	for _, fn := range p.listeners {
+		fn(ev)
+	}
+}
+
+// EventPayload is how events are published to webhooks and the HTTP API
+type EventPayload struct {
+	Event          string    `json:"event"`
+	Mode           string    `json:"mode"`
+	Session        int       `json:"session"`
+	TotalSessions  int       `json:"total_sessions"`
+	Block          string    `json:"block,omitempty"` // Label of the planned block
+	PlannedSeconds int       `json:"planned_seconds"` // Length of the phase as configured
+	ActualSeconds  int       `json:"actual_seconds"`  // Time run so far, short of planned when skipped
+	Task           string    `json:"task,omitempty"`
+	Time           time.Time `json:"time"`
+}
+
+// Payload describes ev for publishing
+func (ev PhaseEvent) Payload() EventPayload {
+	actual := ev.Duration - ev.Remaining
+	if actual < 0 {
+		actual = 0
+	}
+	return EventPayload{
+		Event:          string(ev.Event),
+		Mode:           strings.ToLower(ev.Mode),
+		Session:        ev.Session,
+		TotalSessions:  ev.TotalSessions,
+		Block:          ev.Block,
+		PlannedSeconds: int(ev.Duration.Seconds()),
+		ActualSeconds:  int(actual.Seconds()),
+		Task:           ev.Task,
+		Time:           ev.Time,
+	}
+}
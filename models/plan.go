@@ -0,0 +1,168 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BlockKind is what a planned block is for
+type BlockKind string
+
+const (
+	BlockFocus     BlockKind = "focus"
+	BlockBreak     BlockKind = "break"
+	BlockLongBreak BlockKind = "long break"
+)
+
+// Block is one time box of a plan
+type Block struct {
+	Label    string // What the block is for, e.g. "deep work"
+	Kind     BlockKind
+	Duration time.Duration
+}
+
+// Mode is the timer mode while the block runs
+func (b Block) Mode() Mode {
+	if b.Kind == BlockFocus {
+		return ModeFocus
+	}
+	return ModeBreak
+}
+
+func (b Block) String() string {
+	var words []string
+	if b.Kind != BlockFocus {
+		words = append(words, string(b.Kind))
+	}
+	if b.Label != "" && !strings.EqualFold(b.Label, string(b.Kind)) {
+		words = append(words, b.Label)
+	}
+	return strings.Join(append(words, formatDuration(b.Duration)), " ")
+}
+
+// Plan is the ordered list of blocks the timer runs through in a cycle
+type Plan struct {
+	Start  string // Time of day the plan is meant to start, e.g. "09:00"; "" for whenever
+	Blocks []Block
+}
+
+// DefaultPlan is the classic cycle: three 25 minute focus sessions, each
+// followed by a 5 minute break
+func DefaultPlan() Plan {
+	var plan Plan
+	for i := 0; i < 3; i++ {
+		plan.Blocks = append(plan.Blocks,
+			Block{Label: "focus", Kind: BlockFocus, Duration: 25 * time.Minute},
+			Block{Label: "break", Kind: BlockBreak, Duration: 5 * time.Minute})
+	}
+	return plan
+}
+
+// FocusBlocks counts the focus blocks, the sessions of a cycle
+func (p Plan) FocusBlocks() int {
+	n := 0
+	for _, b := range p.Blocks {
+		if b.Kind == BlockFocus {
+			n++
+		}
+	}
+	return n
+}
+
+// StartTime is when the plan is meant to start on day, false when it has
+// no start time
+func (p Plan) StartTime(day time.Time) (time.Time, bool) {
+	t, err := time.Parse("15:04", p.Start)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), true
+}
+
+// String formats the plan as ParsePlan reads it
+func (p Plan) String() string {
+	items := make([]string, len(p.Blocks))
+	for i, b := range p.Blocks {
+		items[i] = b.String()
+	}
+	text := strings.Join(items, ", ")
+	if p.Start != "" {
+		text = p.Start + " " + text
+	}
+	return text
+}
+
+// ParsePlan reads a plan such as "09:00 deep work 90m, break 15m, code
+// review 45m": blocks separated by commas or new lines, each ending with
+// its length. Blocks starting with "break" or "long break" are breaks, the
+// others focus. Only the first block may have a start time.
+func ParsePlan(text string) (Plan, error) {
+	var plan Plan
+	items := strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '\n' || r == ';' })
+	for i, item := range items {
+		words := strings.Fields(item)
+		if len(words) == 0 {
+			continue
+		}
+		if _, err := time.Parse("15:04", words[0]); err == nil {
+			if i > 0 {
+				return plan, fmt.Errorf("only the first block may have a start time: %q", strings.TrimSpace(item))
+			}
+			t, _ := time.Parse("15:04", words[0])
+			plan.Start = t.Format("15:04")
+			words = words[1:]
+		}
+		block, err := parseBlock(words)
+		if err != nil {
+			return plan, fmt.Errorf("%q: %w", strings.TrimSpace(item), err)
+		}
+		plan.Blocks = append(plan.Blocks, block)
+	}
+	if len(plan.Blocks) == 0 {
+		return plan, fmt.Errorf("the plan has no blocks")
+	}
+	return plan, nil
+}
+
+func parseBlock(words []string) (Block, error) {
+	var block Block
+	if len(words) == 0 {
+		return block, fmt.Errorf("missing length, e.g. 25m")
+	}
+	d, err := time.ParseDuration(words[len(words)-1])
+	if err != nil || d < time.Minute {
+		return block, fmt.Errorf("the block must end with its length, e.g. 25m or 1h30m")
+	}
+	block.Duration = d.Round(time.Minute)
+	words = words[:len(words)-1]
+
+	block.Kind = BlockFocus
+	switch {
+	case len(words) >= 2 && strings.EqualFold(words[0], "long") && strings.EqualFold(words[1], "break"):
+		block.Kind = BlockLongBreak
+		words = words[2:]
+	case len(words) >= 1 && strings.EqualFold(words[0], "break"):
+		block.Kind = BlockBreak
+		words = words[1:]
+	case len(words) >= 1 && strings.EqualFold(words[0], "focus"):
+		words = words[1:]
+	}
+	block.Label = strings.Join(words, " ")
+	if block.Label == "" {
+		block.Label = string(block.Kind)
+	}
+	return block, nil
+}
+
+// formatDuration writes d the way ParsePlan reads it, e.g. 1h30m
+func formatDuration(d time.Duration) string {
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%dm", m)
+	case m == 0:
+		return fmt.Sprintf("%dh", h)
+	}
+	return fmt.Sprintf("%dh%dm", h, m)
+}
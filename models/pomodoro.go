@@ -3,8 +3,6 @@ package models
 import (
 	"embed"
 	"fmt"
-	"io/fs"
-	"os"
 	"time"
 	"zoneout/audio"
 )
@@ -17,46 +15,115 @@ const (
 	ModeBreak
 )
 
-type Session struct {
-	FocusDuration time.Duration
-	BreakDuration time.Duration
-	TotalSessions int
+// FocusSpan describes a focus phase that has ended
+type FocusSpan struct {
+	Start   time.Time
+	End     time.Time
+	Planned time.Duration
+	Actual  time.Duration // Time counted down, short of Planned when skipped
 }
 
 type Pomodoro struct {
-	CurrentMode        Mode
-	Session            Session
-	CurrentSession     int
-	RemainingTime      time.Duration
-	TotalTime          time.Duration
-	IsRunning          bool
-	IsPaused           bool
-	LastTickTime       time.Time
-	CompletedSessions  int
-	audioPlayer        *audio.AudioPlayer
-	startSoundPath     string
-	stopSoundPath      string
-	startSoundTempPath string // For cleanup
-	stopSoundTempPath  string // For cleanup
+	CurrentMode       Mode
+	Plan              Plan
+	Block             int    // Index of the current block in Plan, 0 when idle, -1 when edited out of it
+	BlockLabel        string // Label of the current block, "" when idle
+	CurrentSession    int    // Number of the current focus block
+	TotalSessions     int    // Focus blocks in the plan
+	RemainingTime     time.Duration
+	TotalTime         time.Duration
+	IsRunning         bool
+	IsPaused          bool
+	LastTickTime      time.Time
+	CompletedSessions int
+	Task              string // What the user is working on, reported with events
+	Pauses            int    // Times paused since startup, for metrics
+	Skips             int    // Phases skipped since startup, for metrics
+	Cycles            int    // Full cycles completed since startup
+	Following         bool   // Phases are ended by another instance, see Follow
+	PhaseStarted      time.Time
+	LastFocus         FocusSpan // Set when a focus phase ends
+	audioPlayer       *audio.AudioPlayer
+	startSoundPath    string
+	stopSoundPath     string
+	listeners         []Listener
 }
 
 func NewPomodoro() *Pomodoro {
-	return &Pomodoro{
-		CurrentMode: ModeIdle,
-		Session: Session{
-			FocusDuration: 25 * time.Minute,
-			BreakDuration: 5 * time.Minute,
-			TotalSessions: 3,
-		},
+	p := &Pomodoro{
+		CurrentMode:       ModeIdle,
 		CurrentSession:    0,
-		RemainingTime:     25 * time.Minute,
-		TotalTime:         25 * time.Minute,
 		IsRunning:         false,
 		IsPaused:          false,
 		CompletedSessions: 0,
 		startSoundPath:    "",
 		stopSoundPath:     "",
 	}
+	p.SetPlan(DefaultPlan())
+	return p
+}
+
+// SetPlan replaces the blocks the timer runs through. A block in progress
+// carries on and the new plan applies from the next block: the one after
+// the same block in the new plan, or its first when the current block is
+// no longer in it.
+func (p *Pomodoro) SetPlan(plan Plan) {
+	p.Plan = plan
+	p.TotalSessions = plan.FocusBlocks()
+	if p.CurrentMode == ModeIdle {
+		p.RemainingTime = plan.Blocks[0].Duration
+		p.TotalTime = plan.Blocks[0].Duration
+		return
+	}
+
+	// The block with the same label and kind nearest to where the cycle was
+	current := -1
+	for i, b := range plan.Blocks {
+		if b.Label != p.BlockLabel || b.Mode() != p.CurrentMode {
+			continue
+		}
+		if current < 0 || abs(i-p.Block) < abs(current-p.Block) {
+			current = i
+		}
+	}
+	p.Block = current
+	p.CurrentSession = 0
+	for _, b := range plan.Blocks[:current+1] {
+		if b.Kind == BlockFocus {
+			p.CurrentSession++
+		}
+	}
+	if current < 0 && p.CurrentMode == ModeFocus {
+		// The current session comes on top of the plan's
+		p.CurrentSession = 1
+		p.TotalSessions++
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// enterBlock starts block i of the plan
+func (p *Pomodoro) enterBlock(i int) {
+	block := p.Plan.Blocks[i]
+	p.Block = i
+	p.BlockLabel = block.Label
+	p.CurrentMode = block.Mode()
+	p.RemainingTime = block.Duration
+	p.TotalTime = block.Duration
+	p.PhaseStarted = time.Now()
+	if p.CurrentMode == ModeFocus {
+		p.CurrentSession++
+		p.PlayStartSound() // Mode changed to FOCUS
+		p.emit(EventFocusStart)
+	} else {
+		p.PlayStopSound() // Mode changed to BREAK
+		p.emit(EventBreakStart)
+	}
 }
 
 // SetAudioPlayer sets the audio player for playing transition sounds
@@ -66,43 +133,26 @@ func (p *Pomodoro) SetAudioPlayer(player *audio.AudioPlayer, startSoundPath, sto
 	p.stopSoundPath = stopSoundPath
 }
 
-// SetAudioPlayerWithEmbed sets the audio player and extracts embedded sounds to temp files
-func (p *Pomodoro) SetAudioPlayerWithEmbed(player *audio.AudioPlayer, assetsFS embed.FS) error {
+// SetAudioPlayerWithEmbed sets the audio player and uses the embedded sounds,
+// cached under cacheDir
+func (p *Pomodoro) SetAudioPlayerWithEmbed(player *audio.AudioPlayer, cacheDir string, assetsFS embed.FS) error {
 	p.audioPlayer = player
 
-	// Extract start.mp3
-	startPath, err := extractSoundToTemp(assetsFS, "sounds/start.mp3")
+	startPath, err := audio.CacheAsset(cacheDir, assetsFS, "sounds/start.mp3")
 	if err != nil {
-		return fmt.Errorf("failed to extract start sound: %w", err)
+		return fmt.Errorf("failed to load start sound: %w", err)
 	}
 	p.startSoundPath = startPath
-	p.startSoundTempPath = startPath
 
-	// Extract stop.mp3
-	stopPath, err := extractSoundToTemp(assetsFS, "sounds/stop.mp3")
+	stopPath, err := audio.CacheAsset(cacheDir, assetsFS, "sounds/stop.mp3")
 	if err != nil {
-		// Clean up start sound if stop sound fails
-		os.Remove(startPath)
-		return fmt.Errorf("failed to extract stop sound: %w", err)
+		return fmt.Errorf("failed to load stop sound: %w", err)
 	}
 	p.stopSoundPath = stopPath
-	p.stopSoundTempPath = stopPath
 
 	return nil
 }
 
-// Cleanup removes temporary sound files
-func (p *Pomodoro) Cleanup() {
-	if p.startSoundTempPath != "" {
-		os.Remove(p.startSoundTempPath)
-		p.startSoundTempPath = ""
-	}
-	if p.stopSoundTempPath != "" {
-		os.Remove(p.stopSoundTempPath)
-		p.stopSoundTempPath = ""
-	}
-}
-
 // PlayStartSound plays the start sound effect
 func (p *Pomodoro) PlayStartSound() {
 	if p.audioPlayer != nil && p.startSoundPath != "" {
@@ -117,35 +167,21 @@ func (p *Pomodoro) PlayStopSound() {
 	}
 }
 
-// Helper function to extract embedded sound to temp file
-func extractSoundToTemp(assetsFS embed.FS, path string) (string, error) {
-	data, err := fs.ReadFile(assetsFS, path)
-	if err != nil {
-		return "", fmt.Errorf("failed to read embedded file: %w", err)
-	}
-
-	tmpFile, err := os.CreateTemp("", "zoneout-sound-*.mp3")
-	if err != nil {
-		return "", fmt.Errorf("failed to create temp file: %w", err)
-	}
-
-	if _, err := tmpFile.Write(data); err != nil {
-		tmpFile.Close()
-		os.Remove(tmpFile.Name())
-		return "", fmt.Errorf("failed to write to temp file: %w", err)
+// PlayStartSoundSync plays the start sound and waits for it, returning any error
+func (p *Pomodoro) PlayStartSoundSync() error {
+	if p.audioPlayer == nil || p.startSoundPath == "" {
+		return fmt.Errorf("start sound is not loaded")
 	}
-
-	tmpFile.Close()
-	return tmpFile.Name(), nil
+	return p.audioPlayer.PlaySoundEffectSync(p.startSoundPath)
 }
 
 func (p *Pomodoro) Start() {
+	wasPaused := p.IsPaused
 	if p.CurrentMode == ModeIdle {
-		p.CurrentMode = ModeFocus
-		p.CurrentSession = 1
-		p.RemainingTime = p.Session.FocusDuration
-		p.TotalTime = p.Session.FocusDuration
-		p.PlayStartSound() // Mode changed to FOCUS
+		p.CurrentSession = 0
+		p.enterBlock(0)
+	} else if wasPaused {
+		p.emit(EventResume)
 	}
 	p.IsRunning = true
 	p.IsPaused = false
@@ -156,9 +192,14 @@ func (p *Pomodoro) Start() {
 }
 
 func (p *Pomodoro) Pause() {
+	wasRunning := p.IsRunning
 	p.IsRunning = false
 	p.IsPaused = true
 	p.PlayStopSound() // Status changed to Paused
+	if wasRunning {
+		p.Pauses++
+		p.emit(EventPause)
+	}
 }
 
 func (p *Pomodoro) Resume() {
@@ -167,6 +208,7 @@ func (p *Pomodoro) Resume() {
 		p.IsPaused = false
 		p.LastTickTime = time.Now()
 		p.PlayStartSound() // Status changed to Running
+		p.emit(EventResume)
 	}
 }
 
@@ -174,12 +216,23 @@ func (p *Pomodoro) Stop() {
 	p.IsRunning = false
 	p.IsPaused = false
 	p.CurrentMode = ModeIdle
+	p.Block = 0
+	p.BlockLabel = ""
 	p.CurrentSession = 0
-	p.RemainingTime = p.Session.FocusDuration
-	p.TotalTime = p.Session.FocusDuration
+	p.TotalSessions = p.Plan.FocusBlocks()
+	p.RemainingTime = p.Plan.Blocks[0].Duration
+	p.TotalTime = p.Plan.Blocks[0].Duration
 	p.CompletedSessions = 0
 }
 
+// Reset ends the cycle early, back to idle
+func (p *Pomodoro) Reset() {
+	if p.CurrentMode != ModeIdle {
+		p.emit(EventReset)
+	}
+	p.Stop()
+}
+
 func (p *Pomodoro) Tick(delta time.Duration) bool {
 	if !p.IsRunning {
 		return false
@@ -187,38 +240,163 @@ func (p *Pomodoro) Tick(delta time.Duration) bool {
 
 	p.RemainingTime -= delta
 	if p.RemainingTime <= 0 {
+		if p.Following {
+			// Wait for the instance we follow to move on
+			p.RemainingTime = 0
+			return false
+		}
 		return p.NextPhase()
 	}
 	return false
 }
 
+// Skip ends the current phase early, see NextPhase
+func (p *Pomodoro) Skip() bool {
+	p.Skips++
+	return p.NextPhase()
+}
+
 func (p *Pomodoro) NextPhase() bool {
-	// Check if we're done with the current phase
-	if p.CurrentMode == ModeFocus {
+	// End the current block
+	switch p.CurrentMode {
+	case ModeFocus:
+		p.emit(EventFocusEnd)
+		p.endFocus()
 		p.CompletedSessions++
-		// Switch to break
-		p.CurrentMode = ModeBreak
-		p.RemainingTime = p.Session.BreakDuration
-		p.TotalTime = p.Session.BreakDuration
-		p.PlayStopSound() // Mode changed to BREAK
-		return false
-	} else if p.CurrentMode == ModeBreak {
-		// Check if we've completed all sessions after the break
-		if p.CurrentSession >= p.Session.TotalSessions {
-			p.Stop()
-			return true // All done
-		}
-		// Switch to next focus session
-		p.CurrentSession++
-		p.CurrentMode = ModeFocus
-		p.RemainingTime = p.Session.FocusDuration
-		p.TotalTime = p.Session.FocusDuration
-		p.PlayStartSound() // Mode changed to FOCUS
+	case ModeBreak:
+		p.emit(EventBreakEnd)
+	default:
 		return false
 	}
+
+	// Check if we've been through the whole plan
+	if p.Block+1 >= len(p.Plan.Blocks) {
+		p.Cycles++
+		p.emit(EventCycleComplete)
+		p.Stop()
+		return true // All done
+	}
+	p.enterBlock(p.Block + 1)
 	return false
 }
 
+// EndBy shortens the current phase so it ends at t, reporting whether it
+// had to
+func (p *Pomodoro) EndBy(t time.Time) bool {
+	left := time.Until(t)
+	if left < 0 {
+		left = 0
+	}
+	if p.CurrentMode == ModeIdle || left >= p.RemainingTime {
+		return false
+	}
+	p.TotalTime -= p.RemainingTime - left
+	p.RemainingTime = left
+	return true
+}
+
+// endFocus records the focus phase that is ending in LastFocus
+func (p *Pomodoro) endFocus() {
+	actual := p.TotalTime - p.RemainingTime
+	if actual > p.TotalTime {
+		actual = p.TotalTime
+	}
+	p.LastFocus = FocusSpan{
+		Start:   p.PhaseStarted,
+		End:     time.Now(),
+		Planned: p.TotalTime,
+		Actual:  actual,
+	}
+}
+
+// PhaseState is the timer state another instance can follow
+type PhaseState struct {
+	Mode          Mode
+	Session       int
+	TotalSessions int
+	Label         string // Of the block
+	RemainingTime time.Duration
+	TotalTime     time.Duration
+	IsRunning     bool
+	IsPaused      bool
+	Cycles        int
+}
+
+// State returns the timer state for followers
+func (p *Pomodoro) State() PhaseState {
+	return PhaseState{
+		Mode:          p.CurrentMode,
+		Session:       p.CurrentSession,
+		TotalSessions: p.TotalSessions,
+		Label:         p.BlockLabel,
+		RemainingTime: p.RemainingTime,
+		TotalTime:     p.TotalTime,
+		IsRunning:     p.IsRunning,
+		IsPaused:      p.IsPaused,
+		Cycles:        p.Cycles,
+	}
+}
+
+// Follow moves to a state decided by another instance, with the same
+// sounds and events as a local transition. Like Tick, it reports whether
+// a full cycle was just completed.
+func (p *Pomodoro) Follow(s PhaseState) bool {
+	done := false
+	p.TotalSessions = s.TotalSessions
+	p.BlockLabel = s.Label
+	if s.Mode != p.CurrentMode || s.Session != p.CurrentSession {
+		// End the phase we were in
+		switch {
+		case s.Mode == ModeIdle && s.Cycles <= p.Cycles:
+			// The other instance ended the cycle early
+			if p.CurrentMode != ModeIdle {
+				p.emit(EventReset)
+			}
+		case p.CurrentMode == ModeFocus:
+			p.emit(EventFocusEnd)
+			p.endFocus()
+			p.CompletedSessions++
+		case p.CurrentMode == ModeBreak:
+			p.emit(EventBreakEnd)
+		}
+
+		p.CurrentMode = s.Mode
+		p.CurrentSession = s.Session
+		p.RemainingTime = s.RemainingTime
+		p.TotalTime = s.TotalTime
+		p.PhaseStarted = time.Now().Add(s.RemainingTime - s.TotalTime)
+
+		switch s.Mode {
+		case ModeFocus:
+			p.PlayStartSound()
+			p.emit(EventFocusStart)
+		case ModeBreak:
+			p.PlayStopSound()
+			p.emit(EventBreakStart)
+		case ModeIdle:
+			if s.Cycles > p.Cycles {
+				p.emit(EventCycleComplete)
+				done = true
+			}
+			p.CompletedSessions = 0
+		}
+	}
+	p.Cycles = s.Cycles
+
+	switch {
+	case s.IsPaused && !p.IsPaused:
+		p.Pause()
+	case s.IsRunning && p.IsPaused:
+		p.Resume()
+	}
+	p.IsRunning = s.IsRunning
+	p.IsPaused = s.IsPaused
+	p.RemainingTime = s.RemainingTime
+	p.TotalTime = s.TotalTime
+	p.LastTickTime = time.Now()
+	return done
+}
+
 func (p *Pomodoro) GetModeString() string {
 	switch p.CurrentMode {
 	case ModeFocus:
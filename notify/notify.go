@@ -0,0 +1,242 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"zoneout/models"
+)
+
+// Options selects the notification backends. Desktop notifications are
+// used when a session bus is available; otherwise the terminal is asked to
+// notify with OSC 9 and OSC 777 escapes and the bell rings.
+type Options struct {
+	Desktop  bool
+	Terminal bool
+	Bell     bool
+}
+
+// desktopTimeout bounds a single D-Bus call
+const desktopTimeout = 5 * time.Second
+
+// Terminal is a terminal shared by the notifier and Bubble Tea's renderer.
+// Writes are serialized, and the renderer writes each frame at once, so a
+// notification lands between frames rather than in the middle of one. Pass
+// it to tea.WithOutput too.
+type Terminal struct {
+	*os.File
+	mu sync.Mutex
+}
+
+// NewTerminal shares f, normally os.Stdout
+func NewTerminal(f *os.File) *Terminal {
+	return &Terminal{File: f}
+}
+
+func (t *Terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.File.Write(p)
+}
+
+func (t *Terminal) WriteString(s string) (int, error) {
+	return t.Write([]byte(s))
+}
+
+// Notifier tells the user about phase changes while the terminal may be
+// hidden
+type Notifier struct {
+	desktop      string    // gdbus or notify-send, empty when not in use
+	out          *Terminal // nil when desktop notifications are used
+	osc          bool
+	bell         bool
+	message      func() string // Message of the day for break notifications
+	pendingError error         // Failure not yet reported to the UI
+	mu           sync.Mutex
+}
+
+// New picks the backends from opts that work here. terminal is where
+// escapes are written; it is ignored unless it is a terminal. message, if
+// not nil, supplies text for break notifications.
+func New(opts Options, terminal *Terminal, message func() string) *Notifier {
+	n := &Notifier{message: message}
+	if opts.Desktop && hasSessionBus() {
+		for _, tool := range []string{"gdbus", "notify-send"} {
+			if _, err := exec.LookPath(tool); err == nil {
+				n.desktop = tool
+				break
+			}
+		}
+	}
+	if n.desktop == "" && terminal != nil && isTerminal(terminal.File) {
+		n.out = terminal
+		n.osc = opts.Terminal
+		n.bell = opts.Bell
+	}
+	return n
+}
+
+// Backend describes how notifications are delivered, for diagnostics
+func (n *Notifier) Backend() string {
+	var backends []string
+	if n.desktop != "" {
+		backends = append(backends, "desktop ("+n.desktop+")")
+	}
+	if n.osc {
+		backends = append(backends, "terminal")
+	}
+	if n.bell {
+		backends = append(backends, "bell")
+	}
+	if len(backends) == 0 {
+		return "none"
+	}
+	return strings.Join(backends, " + ")
+}
+
+// Fire notifies about phase changes the user didn't cause themselves. It
+// has the models.Listener signature so it can be registered on a Pomodoro.
+func (n *Notifier) Fire(ev models.PhaseEvent) {
+	switch ev.Event {
+	case models.EventBreakStart:
+		body := fmt.Sprintf("Session %d of %d done.", ev.Session, ev.TotalSessions)
+		if n.message != nil {
+			if msg := n.message(); msg != "" {
+				body += "\n" + msg
+			}
+		}
+		n.Notify("☕ Break time", body)
+	case models.EventFocusStart:
+		// Session 1 is started by the user, the rest follow a break
+		if ev.Session > 1 {
+			n.Notify("🍅 Back to focus", fmt.Sprintf("Session %d of %d", ev.Session, ev.TotalSessions))
+		}
+	case models.EventCycleComplete:
+		n.Notify("🎉 All sessions done", fmt.Sprintf("%d focus sessions completed.", ev.TotalSessions))
+	}
+}
+
+// Notify shows a notification with the configured backends
+func (n *Notifier) Notify(title, body string) {
+	if n.desktop != "" {
+		go func() {
+			if err := n.sendDesktop(title, body); err != nil {
+				n.mu.Lock()
+				n.pendingError = fmt.Errorf("desktop notification failed: %v", err)
+				n.mu.Unlock()
+			}
+		}()
+		return
+	}
+
+	var seq string
+	if n.osc {
+		seq += terminalSequence(title, body)
+	}
+	if n.bell {
+		seq += "\a"
+	}
+	if seq != "" {
+		n.out.WriteString(seq)
+	}
+}
+
+// TakeError returns a notification failure that has not been reported yet
+// and clears it, so the UI shows each failure once
+func (n *Notifier) TakeError() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	err := n.pendingError
+	n.pendingError = nil
+	return err
+}
+
+// sendDesktop calls org.freedesktop.Notifications.Notify on the session bus
+func (n *Notifier) sendDesktop(title, body string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), desktopTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if n.desktop == "gdbus" {
+		// gdbus reads each argument as GVariant text, so quote the strings
+		cmd = exec.CommandContext(ctx, "gdbus", "call", "--session",
+			"--dest", "org.freedesktop.Notifications",
+			"--object-path", "/org/freedesktop/Notifications",
+			"--method", "org.freedesktop.Notifications.Notify",
+			gvariantString("zoneout"), "0", gvariantString(""),
+			gvariantString(title), gvariantString(body),
+			"[]", "{}", "10000")
+	} else {
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=zoneout", "--", title, body)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// terminalSequence builds the OSC 777 (foot, rxvt, Ghostty, WezTerm) and
+// OSC 9 (iTerm2, kitty, Windows Terminal) notifications; terminals ignore
+// the one they don't understand
+func terminalSequence(title, body string) string {
+	title = sanitize(title)
+	body = sanitize(body)
+	seq := "\x1b]777;notify;" + title + ";" + body + "\x07" +
+		"\x1b]9;" + title + ": " + body + "\x07"
+	if os.Getenv("TMUX") != "" {
+		// tmux only forwards escapes wrapped in its passthrough sequence,
+		// with every ESC inside doubled
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
+
+// sanitize keeps text from ending the escape sequence or adding fields
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return ' '
+		case r == ';':
+			return ','
+		case r < 0x20 || r == 0x7f:
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// gvariantString quotes s as a GVariant text string literal
+func gvariantString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
+
+func hasSessionBus() bool {
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") != "" {
+		return true
+	}
+	// Many systems only export XDG_RUNTIME_DIR and use its default bus
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		if _, err := os.Stat(dir + "/bus"); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,224 @@
+// Package orgclock records focus sessions as Org mode CLOCK lines, so Emacs
+// clock tables include the time spent in zoneout.
+package orgclock
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"zoneout/stats"
+)
+
+// DefaultHeading is used for sessions without a task when no heading is set
+const DefaultHeading = "Focus sessions"
+
+// Line formats rec as a CLOCK line, e.g.
+// "CLOCK: [2026-10-18 Sun 09:00]--[2026-10-18 Sun 09:25] =>  0:25"
+func Line(rec stats.SessionRecord) string {
+	start, end := rec.Start.Local(), rec.End.Local()
+	if start.IsZero() {
+		start = end.Add(-time.Duration(rec.Minutes) * time.Minute)
+	}
+	// Org computes the duration from the timestamps, minute by minute
+	start, end = start.Truncate(time.Minute), end.Truncate(time.Minute)
+	minutes := int(end.Sub(start).Minutes())
+	return fmt.Sprintf("CLOCK: [%s]--[%s] => %2d:%02d",
+		start.Format(orgTime), end.Format(orgTime), minutes/60, minutes%60)
+}
+
+const orgTime = "2006-01-02 Mon 15:04"
+
+// Heading is the heading a session is clocked under: heading when set, else
+// the session's task
+func Heading(heading string, rec stats.SessionRecord) string {
+	switch {
+	case heading != "":
+		return heading
+	case rec.Task != "":
+		return rec.Task
+	}
+	return DefaultHeading
+}
+
+// File adds CLOCK lines to an Org file
+type File struct {
+	path    string
+	heading string // "" clocks each session under its task
+}
+
+// NewFile returns a File clocking sessions under heading in the Org file at
+// path
+func NewFile(path, heading string) *File {
+	return &File{path: path, heading: heading}
+}
+
+// Path returns the Org file
+func (f *File) Path() string {
+	return f.path
+}
+
+// Append adds a CLOCK line per session to the LOGBOOK drawer of its
+// heading, newest first as Org does. Missing headings are added at the end
+// of the file, with the session's tags. Sessions already clocked are
+// skipped, so appending again is harmless. It returns how many were added.
+func (f *File) Append(records ...stats.SessionRecord) (int, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to read org file: %w", err)
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(f.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	lines := splitLines(string(data))
+	added := 0
+	for _, rec := range records {
+		var ok bool
+		if lines, ok = insertClock(lines, Heading(f.heading, rec), rec); ok {
+			added++
+		}
+	}
+	if added == 0 {
+		return 0, nil
+	}
+
+	content := strings.Join(lines, "\n") + "\n"
+	tmpPath := f.path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(content), mode); err != nil {
+		return 0, fmt.Errorf("failed to write org file: %w", err)
+	}
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("failed to write org file: %w", err)
+	}
+	return added, nil
+}
+
+// Write writes records as an Org document, one heading per task with its
+// CLOCK lines
+func Write(w io.Writer, heading string, records []stats.SessionRecord) error {
+	var lines []string
+	for _, rec := range records {
+		lines, _ = insertClock(lines, Heading(heading, rec), rec)
+	}
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.WriteString(line + "\n")
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func splitLines(content string) []string {
+	content = strings.TrimRight(content, "\n")
+	if content == "" {
+		return nil
+	}
+	return strings.Split(content, "\n")
+}
+
+var (
+	headingLine  = regexp.MustCompile(`^(\*+)\s+(.*?)\s*$`)
+	headingTags  = regexp.MustCompile(`\s+:[\w@#%:]+:$`)
+	headingTodo  = regexp.MustCompile(`^(TODO|NEXT|STARTED|WAITING|DONE|CANCELLED|CANCELED)\s+`)
+	headingPrio  = regexp.MustCompile(`^\[#[A-Z0-9]\]\s+`)
+	planningLine = regexp.MustCompile(`^\s*(SCHEDULED|DEADLINE|CLOSED):`)
+)
+
+// headingTitle returns the title of an Org heading without its TODO
+// keyword, priority and tags
+func headingTitle(line string) (string, bool) {
+	m := headingLine.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+	title := headingTags.ReplaceAllString(m[2], "")
+	title = headingTodo.ReplaceAllString(title, "")
+	title = headingPrio.ReplaceAllString(title, "")
+	return strings.TrimSpace(title), true
+}
+
+// insertClock adds rec's CLOCK line under heading, reporting false when the
+// line is there already
+func insertClock(lines []string, heading string, rec stats.SessionRecord) ([]string, bool) {
+	clock := Line(rec)
+
+	at := -1
+	for i, line := range lines {
+		if title, ok := headingTitle(line); ok && strings.EqualFold(title, heading) {
+			at = i
+			break
+		}
+	}
+	if at < 0 {
+		title := "* " + heading
+		if tags := orgTags(rec.Tags); tags != "" {
+			title += " " + tags
+		}
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		return append(lines, title, ":LOGBOOK:", clock, ":END:"), true
+	}
+
+	// The logbook follows the planning line and the properties drawer
+	i := at + 1
+	for i < len(lines) && planningLine.MatchString(lines[i]) {
+		i++
+	}
+	if i < len(lines) && strings.TrimSpace(lines[i]) == ":PROPERTIES:" {
+		for i < len(lines) && strings.TrimSpace(lines[i]) != ":END:" {
+			i++
+		}
+		i++
+	}
+
+	if i < len(lines) && strings.TrimSpace(lines[i]) == ":LOGBOOK:" {
+		indent := lines[i][:len(lines[i])-len(strings.TrimLeft(lines[i], " \t"))]
+		for j := i + 1; j < len(lines) && strings.TrimSpace(lines[j]) != ":END:"; j++ {
+			if strings.TrimSpace(lines[j]) == clock {
+				return lines, false
+			}
+		}
+		return insertLines(lines, i+1, indent+clock), true
+	}
+	return insertLines(lines, min(i, len(lines)), ":LOGBOOK:", clock, ":END:"), true
+}
+
+func insertLines(lines []string, at int, insert ...string) []string {
+	result := make([]string, 0, len(lines)+len(insert))
+	result = append(result, lines[:at]...)
+	result = append(result, insert...)
+	return append(result, lines[at:]...)
+}
+
+// orgTags formats tags as Org heading tags, e.g. ":acme:web:". Org allows
+// letters, digits, _, @, # and % in tags.
+func orgTags(tags []string) string {
+	var valid []string
+	for _, tag := range tags {
+		tag = strings.Map(func(r rune) rune {
+			if r == '_' || r == '@' || r == '#' || r == '%' || isAlnum(r) {
+				return r
+			}
+			return '_'
+		}, tag)
+		if tag != "" {
+			valid = append(valid, tag)
+		}
+	}
+	if len(valid) == 0 {
+		return ""
+	}
+	return ":" + strings.Join(valid, ":") + ":"
+}
+
+func isAlnum(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r > 127
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"zoneout/stats"
+)
+
+// runReport prints the focus time per tag (or task) over a date range, by
+// default the current week
+func runReport(args []string, configDir string) int {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	since := fs.String("since", "week", "first day: YYYY-MM-DD, Nd for the last N days, today, week, last-week, month or all")
+	until := fs.String("until", "", "last day, YYYY-MM-DD (default today)")
+	// The names report had before it shared export's flags
+	for _, name := range []string{"period", "from"} {
+		fs.Func(name, "same as -since", func(s string) error { *since = s; return nil })
+	}
+	fs.Func("to", "same as -until", func(s string) error { *until = s; return nil })
+	by := fs.String("by", "tag", "group sessions by tag or task")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	start, end, err := parseRange(*since, *until, time.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	group := stats.ByTag
+	switch *by {
+	case "tag":
+	case "task":
+		group = stats.ByTask
+	default:
+		fmt.Fprintf(os.Stderr, "-by must be tag or task, not %q\n", *by)
+		return 2
+	}
+
+	records, err := stats.NewStatsWithPath(configDir).History()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	totals := stats.Totals(records, start, end, group)
+	sum := stats.Sum(records, start, end)
+
+	if *asJSON {
+		report := struct {
+			From                  string        `json:"from,omitempty"`
+			To                    string        `json:"to,omitempty"`
+			Sessions              int           `json:"sessions"`
+			Minutes               int           `json:"minutes"`
+			InternalInterruptions int           `json:"internal_interruptions"`
+			ExternalInterruptions int           `json:"external_interruptions"`
+			Totals                []stats.Total `json:"totals"`
+		}{
+			Sessions:              sum.Sessions,
+			Minutes:               sum.Minutes,
+			InternalInterruptions: sum.Internal,
+			ExternalInterruptions: sum.External,
+			Totals:                totals,
+		}
+		if !start.IsZero() {
+			report.From = start.Format("2006-01-02")
+		}
+		if !end.IsZero() {
+			report.To = end.AddDate(0, 0, -1).Format("2006-01-02")
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+		return 0
+	}
+
+	fmt.Println(describeRange(start, end))
+	if len(totals) == 0 {
+		fmt.Println("No focus sessions.")
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tSESSIONS\tTIME\tINTERNAL\tEXTERNAL\n", strings.ToUpper(*by))
+	for _, total := range totals {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%d\n", total.Name, total.Sessions, formatReportMinutes(total.Minutes), total.Internal, total.External)
+	}
+	w.Flush()
+	fmt.Printf("\nTotal: %s in %d sessions, interrupted %d times internally and %d externally\n",
+		formatReportMinutes(sum.Minutes), sum.Sessions, sum.Internal, sum.External)
+	if *by == "tag" {
+		fmt.Println("Sessions with several tags count towards each tag.")
+	}
+	return 0
+}
+
+// parseRange turns -since and -until into [start, end) at local midnights;
+// zero times leave the range open. since is a first day (YYYY-MM-DD), Nd for
+// the last N days, today, week, last-week, month or all (also ""), and until
+// a last day. export and report share it so both take the same ranges.
+func parseRange(since, until string, now time.Time) (start, end time.Time, err error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	end = today.AddDate(0, 0, 1)
+
+	switch since {
+	case "", "all":
+		end = time.Time{}
+	case "today":
+		start = today
+	case "week":
+		start = stats.Week(today)
+	case "last-week":
+		end = stats.Week(today)
+		start = end.AddDate(0, 0, -7)
+	case "month":
+		start = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	default:
+		if days, ok := strings.CutSuffix(since, "d"); ok {
+			if n, err := strconv.Atoi(days); err == nil && n > 0 {
+				start = today.AddDate(0, 0, 1-n)
+				break
+			}
+		}
+		if start, err = time.ParseInLocation("2006-01-02", since, now.Location()); err != nil {
+			return start, end, fmt.Errorf("invalid -since %q, use YYYY-MM-DD, Nd, today, week, last-week, month or all", since)
+		}
+	}
+
+	if until != "" {
+		day, err := time.ParseInLocation("2006-01-02", until, now.Location())
+		if err != nil {
+			return start, end, fmt.Errorf("invalid -until date %q, use YYYY-MM-DD", until)
+		}
+		end = day.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+func describeRange(start, end time.Time) string {
+	switch {
+	case start.IsZero() && end.IsZero():
+		return "All focus sessions"
+	case start.IsZero():
+		return fmt.Sprintf("Focus sessions until %s", end.AddDate(0, 0, -1).Format("Mon Jan 2 2006"))
+	}
+	last := end.AddDate(0, 0, -1)
+	if last.Equal(start) {
+		return fmt.Sprintf("Focus sessions on %s", start.Format("Mon Jan 2 2006"))
+	}
+	return fmt.Sprintf("Focus sessions %s to %s", start.Format("Mon Jan 2"), last.Format("Mon Jan 2 2006"))
+}
+
+// formatReportMinutes shows minutes as hours and minutes, e.g. "3h05m"
+func formatReportMinutes(minutes int) string {
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"zoneout/audio"
+	"zoneout/config"
+)
+
+// runSleep turns zoneout into a night-time noise machine: it plays ambient
+// audio for the given duration, fades it out and exits
+func runSleep(args []string, configDir string, assetsFS embed.FS) int {
+	fs := flag.NewFlagSet("sleep", flag.ExitOnError)
+	sound := fs.String("sound", "", "play the sound whose file name contains this text")
+	fade := fs.Duration("fade", 0, "fade-out length (default from config)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: zoneout sleep [flags] [duration]   (default 30m)")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	duration := 30 * time.Minute
+	if fs.NArg() > 0 {
+		d, err := time.ParseDuration(fs.Arg(0))
+		if err != nil || d <= 0 {
+			fmt.Fprintf(os.Stderr, "Invalid duration %q\n", fs.Arg(0))
+			return 2
+		}
+		duration = d
+	}
+
+	defer audio.KillAll()
+	audio.RemoveStaleTempFiles()
+
+	whitenoiseDir := filepath.Join(configDir, "whitenoise")
+	cacheDir := filepath.Join(configDir, "cache")
+	audioPlayer, err := audio.NewAudioPlayerWithEmbed(whitenoiseDir, cacheDir, assetsFS)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize audio player: %v\n", err)
+		return 1
+	}
+	defer audioPlayer.Stop()
+
+	appConfig := config.NewConfig(configDir)
+	audioPlayer.SetVolume(appConfig.GetVolume())
+	if *fade == 0 {
+		*fade = appConfig.GetFadeOut()
+	}
+	if *fade > duration {
+		*fade = duration
+	}
+
+	sounds := audioPlayer.GetAvailableMP3s()
+	if len(sounds) == 0 {
+		fmt.Fprintf(os.Stderr, "No sounds available in %s (run 'zoneout doctor')\n", whitenoiseDir)
+		return 1
+	}
+	path := sounds[0]
+	if *sound != "" {
+		path = ""
+		for _, s := range sounds {
+			if strings.Contains(strings.ToLower(filepath.Base(s)), strings.ToLower(*sound)) {
+				path = s
+				break
+			}
+		}
+		if path == "" {
+			fmt.Fprintf(os.Stderr, "No sound matching %q\n", *sound)
+			return 1
+		}
+	}
+
+	if err := audioPlayer.PlayWithFadeOut(path, duration-*fade, *fade); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to play %s: %v\n", filepath.Base(path), err)
+		return 1
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+
+	end := time.Now().Add(duration)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	fmt.Printf("🌙 Playing %s for %s (Ctrl+C to stop)\n", filepath.Base(path), duration)
+	for {
+		remaining := time.Until(end).Round(time.Second)
+		if remaining <= 0 {
+			fmt.Println("\rGood night.          ")
+			return 0
+		}
+		fmt.Printf("\r%s left ", remaining)
+
+		select {
+		case <-sigs:
+			fmt.Println()
+			return 0
+		case <-ticker.C:
+		}
+	}
+}
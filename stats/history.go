@@ -0,0 +1,252 @@
+package stats
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+)
+
+// SessionRecord is one completed focus session
+type SessionRecord struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Minutes int       `json:"minutes"` // Time spent focusing, pauses excluded
+	TaskID  string    `json:"task_id,omitempty"`
+	Task    string    `json:"task,omitempty"` // Title of the task at the time
+	Tags    []string  `json:"tags,omitempty"` // Projects, clients or activities
+
+	Interruptions []Interruption `json:"interruptions,omitempty"`
+}
+
+// Interruption kinds, as in the Pomodoro Technique
+const (
+	InterruptionInternal = "internal" // Your own urge to do something else
+	InterruptionExternal = "external" // Someone or something else
+)
+
+// Interruption is a distraction logged during a focus session
+type Interruption struct {
+	At   time.Time `json:"at"`
+	Kind string    `json:"kind"` // InterruptionInternal or InterruptionExternal
+	Note string    `json:"note,omitempty"`
+}
+
+// InterruptionCounts returns the number of internal and external
+// interruptions logged during the session
+func (rec SessionRecord) InterruptionCounts() (internal, external int) {
+	for _, in := range rec.Interruptions {
+		if in.Kind == InterruptionExternal {
+			external++
+		} else {
+			internal++
+		}
+	}
+	return internal, external
+}
+
+// TaskTotal is the focus time spent on one task
+type TaskTotal struct {
+	Sessions int
+	Minutes  int
+}
+
+// historyPath is the session history, one JSON record per line next to the
+// stats file so it can grow without slowing down the stats
+func (s *Stats) historyPath() string {
+	if s.statsFile == "" {
+		return ".zoneout_sessions"
+	}
+	return filepath.Join(filepath.Dir(s.statsFile), ".zoneout_sessions")
+}
+
+// lockHistory keeps a running instance from recording sessions while an
+// import rewrites the history. The lock is a file of its own, as Import
+// replaces the history file.
+func (s *Stats) lockHistory() (unlock func(), err error) {
+	unlock, err = lockFile(s.historyPath() + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock session history: %w", err)
+	}
+	return unlock, nil
+}
+
+// RecordSession counts a completed focus session and adds it to the history
+func (s *Stats) RecordSession(rec SessionRecord) error {
+	unlock, err := s.lockHistory()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.AddSession(rec.Minutes); err != nil {
+		return err
+	}
+	return s.appendHistory(rec)
+}
+
+func (s *Stats) appendHistory(rec SessionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	f, err := os.OpenFile(s.historyPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open session history: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write session history: %w", err)
+	}
+	return nil
+}
+
+// History returns every recorded session, oldest first. Lines that can't
+// be parsed are skipped.
+func (s *Stats) History() ([]SessionRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.historyPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read session history: %w", err)
+	}
+	defer f.Close()
+
+	var records []SessionRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec SessionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return records, fmt.Errorf("failed to read session history: %w", err)
+	}
+	return records, nil
+}
+
+// Import merges records from elsewhere into the history, skipping those
+// that start in the same minute as a session already recorded, and returns
+// how many were added. The history is locked meanwhile, so sessions a
+// running instance completes wait rather than get lost. The counters are
+// then rebuilt from the merged history, keeping what they held beyond the
+// old history: sessions counted before there was one.
+func (s *Stats) Import(records []SessionRecord) (int, error) {
+	unlock, err := s.lockHistory()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	history, err := s.History()
+	if err != nil {
+		return 0, err
+	}
+
+	seen := make(map[int64]bool)
+	for _, rec := range history {
+		seen[importKey(rec)] = true
+	}
+	var added []SessionRecord
+	for _, rec := range records {
+		if key := importKey(rec); !seen[key] {
+			seen[key] = true
+			added = append(added, rec)
+		}
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+
+	merged := append(slices.Clip(history), added...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return importKey(merged[i]) < importKey(merged[j])
+	})
+	if err := s.writeHistory(merged); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadLocked() // A corrupt file is replaced, as by AddSession
+	old := Sum(history, time.Time{}, time.Time{})
+	total := Sum(merged, time.Time{}, time.Time{})
+	s.TotalSessions = total.Sessions + max(s.TotalSessions-old.Sessions, 0)
+	s.TotalFocusMinutes = total.Minutes + max(s.TotalFocusMinutes-old.Minutes, 0)
+
+	today := time.Now().Format("2006-01-02")
+	s.TodaySessions = 0
+	s.LastSessionDate = today
+	for _, rec := range merged {
+		if rec.End.Local().Format("2006-01-02") == today {
+			s.TodaySessions++
+		}
+	}
+	return len(added), s.saveLocked()
+}
+
+// importKey identifies a session by the minute it started
+func importKey(rec SessionRecord) int64 {
+	start := rec.Start
+	if start.IsZero() {
+		start = rec.End.Add(-time.Duration(rec.Minutes) * time.Minute)
+	}
+	return start.Unix() / 60
+}
+
+// writeHistory replaces the history with records, atomically
+func (s *Stats) writeHistory(records []SessionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var buf bytes.Buffer
+	for _, rec := range records {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("failed to marshal session: %w", err)
+		}
+		buf.Write(append(data, '\n'))
+	}
+
+	path := s.historyPath()
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write session history: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write session history: %w", err)
+	}
+	return nil
+}
+
+// TotalsByTask adds up the sessions in records per task ID
+func TotalsByTask(records []SessionRecord) map[string]TaskTotal {
+	totals := make(map[string]TaskTotal)
+	for _, rec := range records {
+		if rec.TaskID == "" {
+			continue
+		}
+		total := totals[rec.TaskID]
+		total.Sessions++
+		total.Minutes += rec.Minutes
+		totals[rec.TaskID] = total
+	}
+	return totals
+}
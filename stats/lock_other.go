@@ -0,0 +1,9 @@
+//go:build !unix
+
+package stats
+
+// lockFile does nothing where flock isn't available; an import then only
+// has the atomic rename of the history to rely on
+func lockFile(path string) (unlock func(), err error) {
+	return func() {}, nil
+}
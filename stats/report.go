@@ -0,0 +1,125 @@
+package stats
+
+import (
+	"sort"
+	"time"
+)
+
+// Untagged is the name sessions without tags are reported under
+const Untagged = "(untagged)"
+
+// Total is the focus time recorded under one tag or task
+type Total struct {
+	Name     string `json:"name"`
+	Sessions int    `json:"sessions"`
+	Minutes  int    `json:"minutes"`
+	Internal int    `json:"internal_interruptions"`
+	External int    `json:"external_interruptions"`
+}
+
+// ByTag groups sessions by tag. A session with several tags counts towards
+// each of them.
+func ByTag(rec SessionRecord) []string {
+	if len(rec.Tags) == 0 {
+		return []string{Untagged}
+	}
+	return rec.Tags
+}
+
+// ByTask groups sessions by task title
+func ByTask(rec SessionRecord) []string {
+	if rec.Task == "" {
+		return []string{"(no task)"}
+	}
+	return []string{rec.Task}
+}
+
+// Totals adds up the sessions that started in [from, to), grouped by
+// group, most time first. A zero from or to leaves that end open.
+func Totals(records []SessionRecord, from, to time.Time, group func(SessionRecord) []string) []Total {
+	byName := make(map[string]*Total)
+	for _, rec := range records {
+		if !InRange(rec, from, to) {
+			continue
+		}
+		for _, name := range group(rec) {
+			total, ok := byName[name]
+			if !ok {
+				total = &Total{Name: name}
+				byName[name] = total
+			}
+			internal, external := rec.InterruptionCounts()
+			total.Sessions++
+			total.Minutes += rec.Minutes
+			total.Internal += internal
+			total.External += external
+		}
+	}
+
+	totals := make([]Total, 0, len(byName))
+	for _, total := range byName {
+		totals = append(totals, *total)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Minutes != totals[j].Minutes {
+			return totals[i].Minutes > totals[j].Minutes
+		}
+		return totals[i].Name < totals[j].Name
+	})
+	return totals
+}
+
+// Sum adds up all the sessions that started in [from, to), counting each
+// once whatever its tags
+func Sum(records []SessionRecord, from, to time.Time) Total {
+	var total Total
+	for _, rec := range records {
+		if !InRange(rec, from, to) {
+			continue
+		}
+		internal, external := rec.InterruptionCounts()
+		total.Sessions++
+		total.Minutes += rec.Minutes
+		total.Internal += internal
+		total.External += external
+	}
+	return total
+}
+
+// InRange reports whether rec started in [from, to); a zero from or to
+// leaves that end open
+func InRange(rec SessionRecord, from, to time.Time) bool {
+	start := rec.Start
+	if start.IsZero() {
+		start = rec.End
+	}
+	if !from.IsZero() && start.Before(from) {
+		return false
+	}
+	if !to.IsZero() && !start.Before(to) {
+		return false
+	}
+	return true
+}
+
+// RecentTags lists the tags used in records, most recently used first
+func RecentTags(records []SessionRecord) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for i := len(records) - 1; i >= 0; i-- {
+		for _, tag := range records[i].Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// Week returns the start of the week containing t, Monday at midnight
+func Week(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+	return day.AddDate(0, 0, -offset)
+}
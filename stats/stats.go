@@ -35,7 +35,12 @@ func NewStatsWithPath(configDir string) *Stats {
 func (s *Stats) Load() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.loadLocked()
+}
 
+// loadLocked reads the stats file, s.mu must be held. The counters are
+// left alone if the file can't be parsed.
+func (s *Stats) loadLocked() error {
 	// Use default if not set
 	statsPath := s.statsFile
 	if statsPath == "" {
@@ -54,9 +59,14 @@ func (s *Stats) Load() error {
 	}
 
 	// Parse JSON
-	if err := json.Unmarshal(data, s); err != nil {
+	var loaded Stats
+	if err := json.Unmarshal(data, &loaded); err != nil {
 		return fmt.Errorf("failed to parse stats file: %w", err)
 	}
+	s.TotalSessions = loaded.TotalSessions
+	s.TodaySessions = loaded.TodaySessions
+	s.LastSessionDate = loaded.LastSessionDate
+	s.TotalFocusMinutes = loaded.TotalFocusMinutes
 
 	// Check if it's a new day
 	today := time.Now().Format("2006-01-02")
@@ -71,7 +81,11 @@ func (s *Stats) Load() error {
 func (s *Stats) Save() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.saveLocked()
+}
 
+// saveLocked writes the stats file, s.mu must be held
+func (s *Stats) saveLocked() error {
 	// Use default if not set
 	statsPath := s.statsFile
 	if statsPath == "" {
@@ -90,15 +104,15 @@ func (s *Stats) Save() error {
 	return nil
 }
 
+// AddSession counts a completed focus session. The file is read again
+// first, so sessions counted by an import or another instance since it was
+// loaded are kept.
 func (s *Stats) AddSession(focusMinutes int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Use default if not set
-	statsPath := s.statsFile
-	if statsPath == "" {
-		statsPath = ".zoneout_stats"
-	}
+	// A corrupt file is replaced with the counts in memory
+	s.loadLocked()
 
 	today := time.Now().Format("2006-01-02")
 
@@ -112,17 +126,7 @@ func (s *Stats) AddSession(focusMinutes int) error {
 	s.TotalFocusMinutes += focusMinutes
 	s.LastSessionDate = today
 
-	// Save to file
-	data, err := json.MarshalIndent(s, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal stats: %w", err)
-	}
-
-	if err := os.WriteFile(statsPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write stats file: %w", err)
-	}
-
-	return nil
+	return s.saveLocked()
 }
 
 func (s *Stats) GetTotalSessions() int {
@@ -131,6 +135,18 @@ func (s *Stats) GetTotalSessions() int {
 	return s.TotalSessions
 }
 
+func (s *Stats) GetTotalFocusMinutes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.TotalFocusMinutes
+}
+
+func (s *Stats) GetLastSessionDate() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.LastSessionDate
+}
+
 func (s *Stats) GetTodaySessions() int {
 	// Reload from disk to ensure we have the latest value
 	s.Load()
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"zoneout/control"
+	"zoneout/statusbar"
+)
+
+// runStatus prints the timer state in a status-bar friendly format. With
+// -watch it prints a new line every second, which suits waybar's
+// continuous custom modules.
+func runStatus(args []string, configDir string) int {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	format := fs.String("format", statusbar.FormatPlain, "output format: plain, tmux or waybar")
+	watch := fs.Bool("watch", false, "keep printing the status every second")
+	fs.Parse(args)
+
+	printStatus := func() error {
+		out, err := statusbar.Format(currentStatus(configDir), *format)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+		return nil
+	}
+
+	if err := printStatus(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if !*watch {
+		return 0
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sigs:
+			return 0
+		case <-ticker.C:
+			if err := printStatus(); err != nil {
+				return 1
+			}
+		}
+	}
+}
+
+// currentStatus asks the running instance, falling back to the status file;
+// nil means zoneout isn't running
+func currentStatus(configDir string) *control.Status {
+	resp, err := control.Send(control.SocketPath(configDir), control.Request{Command: control.CommandStatus})
+	if err == nil && resp.Status != nil {
+		return resp.Status
+	}
+	return statusbar.ReadFile(statusbar.FilePath(configDir))
+}
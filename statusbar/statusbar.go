@@ -0,0 +1,180 @@
+package statusbar
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"zoneout/control"
+)
+
+// Supported output formats
+const (
+	FormatPlain  = "plain"
+	FormatTmux   = "tmux"
+	FormatWaybar = "waybar"
+)
+
+// staleAfter is how old a status file may be before the instance that wrote
+// it is assumed to be gone
+const staleAfter = 5 * time.Second
+
+// FilePath is the status file a running instance keeps up to date
+func FilePath(configDir string) string {
+	return filepath.Join(configDir, "status.json")
+}
+
+// fileContents is the JSON written to the status file
+type fileContents struct {
+	control.Status
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WriteFile atomically replaces the status file, so bars polling it never
+// read a partial write
+func WriteFile(path string, status control.Status) error {
+	data, err := json.MarshalIndent(fileContents{Status: status, UpdatedAt: time.Now()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal status: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write status file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write status file: %w", err)
+	}
+	return nil
+}
+
+// ReadFile returns the status from the status file, or nil if it is missing
+// or too old to trust
+func ReadFile(path string) *control.Status {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var contents fileContents
+	if err := json.Unmarshal(data, &contents); err != nil {
+		return nil
+	}
+	if time.Since(contents.UpdatedAt) > staleAfter {
+		return nil
+	}
+	return &contents.Status
+}
+
+// Emoji returns the icon for the current mode; paused wins over the mode
+func Emoji(s *control.Status) string {
+	if s == nil {
+		return "💤"
+	}
+	if s.Paused {
+		return "⏸"
+	}
+	switch s.Mode {
+	case "focus":
+		return "🍅"
+	case "break":
+		return "☕"
+	default:
+		return "💤"
+	}
+}
+
+// class names the state for styling: focus, break, paused, idle or offline
+func class(s *control.Status) string {
+	if s == nil {
+		return "offline"
+	}
+	if s.Paused {
+		return "paused"
+	}
+	return s.Mode
+}
+
+// Format renders a status for a bar; a nil status means zoneout isn't running
+func Format(s *control.Status, format string) (string, error) {
+	switch format {
+	case FormatPlain:
+		return formatPlain(s), nil
+	case FormatTmux:
+		return formatTmux(s), nil
+	case FormatWaybar:
+		return formatWaybar(s)
+	default:
+		return "", fmt.Errorf("unknown format %q (use plain, tmux or waybar)", format)
+	}
+}
+
+// text is the common "<emoji> MM:SS n/N" body shared by every format
+func text(s *control.Status) string {
+	if s == nil {
+		return ""
+	}
+	if s.Mode == "idle" {
+		return Emoji(s) + " idle"
+	}
+	return fmt.Sprintf("%s %s %d/%d", Emoji(s), s.Remaining, s.Session, s.TotalSessions)
+}
+
+func formatPlain(s *control.Status) string {
+	return text(s)
+}
+
+func formatTmux(s *control.Status) string {
+	if s == nil {
+		return ""
+	}
+	// Same palette as the TUI
+	colors := map[string]string{
+		"focus":  "#FF6B6B",
+		"break":  "#6BCF7F",
+		"paused": "#FFD93D",
+		"idle":   "#A0E7E5",
+	}
+	return fmt.Sprintf("#[fg=%s]%s#[default]", colors[class(s)], text(s))
+}
+
+// waybarOutput is the JSON a waybar custom module expects
+type waybarOutput struct {
+	Text       string `json:"text"`
+	Tooltip    string `json:"tooltip"`
+	Class      string `json:"class"`
+	Alt        string `json:"alt"`
+	Percentage int    `json:"percentage"`
+}
+
+func formatWaybar(s *control.Status) (string, error) {
+	out := waybarOutput{Class: class(s), Alt: class(s)}
+	if s == nil {
+		out.Tooltip = "zoneout is not running"
+	} else {
+		out.Text = text(s)
+
+		var tooltip []string
+		tooltip = append(tooltip, fmt.Sprintf("%s - session %d of %d", strings.ToUpper(s.Mode), s.Session, s.TotalSessions))
+		if s.Sound != "" {
+			tooltip = append(tooltip, "Sound: "+s.Sound)
+		}
+		if s.Tone != "" {
+			tooltip = append(tooltip, "Tone: "+s.Tone)
+		}
+		out.Tooltip = strings.Join(tooltip, "\n")
+
+		if s.TotalSeconds > 0 {
+			out.Percentage = 100 * (s.TotalSeconds - s.RemainingSeconds) / s.TotalSeconds
+		}
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal waybar output: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,276 @@
+// Package tasks keeps the list of things the user works on during focus
+// sessions. Completed sessions are attributed to the active task in the
+// session history, see stats.SessionRecord.
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Task is one item on the list
+type Task struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Priority  string    `json:"priority,omitempty"` // "A" (highest) to "Z", from todo.txt
+	Projects  []string  `json:"projects,omitempty"`
+	Contexts  []string  `json:"contexts,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`     // From Taskwarrior
+	Estimate  int       `json:"estimate,omitempty"` // Pomodoros the task is expected to take, 0 if unknown
+	Pomodoros int       `json:"pomodoros,omitempty"`
+	Done      bool      `json:"done,omitempty"`
+	Created   time.Time `json:"created"`
+	Completed time.Time `json:"completed,omitzero"`
+}
+
+// Source is a task list the task panel works on: the built-in Store or an
+// external one such as a todo.txt file. IDs are opaque and only stable for
+// as long as the task isn't edited.
+type Source interface {
+	List() []Task
+	Add(input string) (Task, error)
+	Edit(id, input string) error
+	SetEstimate(id string, estimate int) error
+	SetDone(id string, done bool) error
+	AddPomodoro(id string) error // Counts a completed focus session
+	SetActive(id string) error
+	Active() (Task, bool)
+}
+
+// Formatter is implemented by sources whose input syntax differs from
+// FormatTitle's
+type Formatter interface {
+	Format(t Task) string
+}
+
+// Format turns t back into what source's Add and Edit accept
+func Format(source Source, t Task) string {
+	if f, ok := source.(Formatter); ok {
+		return f.Format(t)
+	}
+	return FormatTitle(t)
+}
+
+// Store is the built-in task list, saved in the config directory
+type Store struct {
+	Tasks    []Task `json:"tasks"`
+	ActiveID string `json:"active,omitempty"` // Task the current focus sessions count towards
+	NextID   int    `json:"next_id"`
+	file     string
+	mu       sync.Mutex
+}
+
+// NewStore loads the task list from configDir. A missing or unreadable
+// file gives an empty list, Load reports why.
+func NewStore(configDir string) *Store {
+	s := &Store{
+		file:   filepath.Join(configDir, "tasks.json"),
+		NextID: 1,
+	}
+	s.Load()
+	return s
+}
+
+func (s *Store) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read tasks: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("failed to parse tasks file: %w", err)
+	}
+	return nil
+}
+
+// saveLocked writes the list atomically; s.mu must be held
+func (s *Store) saveLocked() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal tasks: %w", err)
+	}
+
+	tmpPath := s.file + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write tasks file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.file); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write tasks file: %w", err)
+	}
+	return nil
+}
+
+// List returns a copy of the tasks, open ones first, in the order they
+// were added
+func (s *Store) List() []Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Task, 0, len(s.Tasks))
+	for _, done := range []bool{false, true} {
+		for _, t := range s.Tasks {
+			if t.Done == done {
+				list = append(list, t)
+			}
+		}
+	}
+	return list
+}
+
+// Add appends a task. An input ending in "~N", e.g. "Write report ~3",
+// sets the estimate to N pomodoros.
+func (s *Store) Add(title string) (Task, error) {
+	title, estimate := ParseTitle(title)
+	if title == "" {
+		return Task{}, fmt.Errorf("task title is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := Task{
+		ID:       strconv.Itoa(s.NextID),
+		Title:    title,
+		Estimate: estimate,
+		Created:  time.Now(),
+	}
+	s.NextID++
+	s.Tasks = append(s.Tasks, t)
+	return t, s.saveLocked()
+}
+
+// Edit changes a task's title and estimate, parsed as for Add
+func (s *Store) Edit(id, title string) error {
+	title, estimate := ParseTitle(title)
+	if title == "" {
+		return fmt.Errorf("task title is empty")
+	}
+	return s.update(id, func(t *Task) {
+		t.Title = title
+		t.Estimate = estimate
+	})
+}
+
+// SetEstimate changes the number of pomodoros a task is expected to take
+func (s *Store) SetEstimate(id string, estimate int) error {
+	if estimate < 0 {
+		estimate = 0
+	}
+	return s.update(id, func(t *Task) {
+		t.Estimate = estimate
+	})
+}
+
+// SetDone marks a task complete or open again. Completing the active task
+// leaves no task active.
+func (s *Store) SetDone(id string, done bool) error {
+	return s.update(id, func(t *Task) {
+		t.Done = done
+		t.Completed = time.Time{}
+		if done {
+			t.Completed = time.Now()
+			if s.ActiveID == id {
+				s.ActiveID = ""
+			}
+		}
+	})
+}
+
+// AddPomodoro counts a completed focus session towards a task
+func (s *Store) AddPomodoro(id string) error {
+	return s.update(id, func(t *Task) {
+		t.Pomodoros++
+	})
+}
+
+// SetActive selects the task focus sessions count towards, "" for none
+func (s *Store) SetActive(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id != "" && s.findLocked(id) == nil {
+		return fmt.Errorf("no task %q", id)
+	}
+	s.ActiveID = id
+	return s.saveLocked()
+}
+
+// Active returns the active task, if there is one
+func (s *Store) Active() (Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t := s.findLocked(s.ActiveID); t != nil {
+		return *t, true
+	}
+	return Task{}, false
+}
+
+func (s *Store) update(id string, change func(t *Task)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.findLocked(id)
+	if t == nil {
+		return fmt.Errorf("no task %q", id)
+	}
+	change(t)
+	return s.saveLocked()
+}
+
+func (s *Store) findLocked(id string) *Task {
+	if id == "" {
+		return nil
+	}
+	for i := range s.Tasks {
+		if s.Tasks[i].ID == id {
+			return &s.Tasks[i]
+		}
+	}
+	return nil
+}
+
+// ParseTitle splits a trailing "~N" estimate off a task title
+func ParseTitle(input string) (title string, estimate int) {
+	title = strings.TrimSpace(input)
+	i := strings.LastIndex(title, "~")
+	if i < 0 {
+		return title, 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(title[i+1:]))
+	if err != nil || n < 0 {
+		return title, 0
+	}
+	return strings.TrimSpace(title[:i]), n
+}
+
+// FormatTitle turns a task back into what Add accepts, for editing it
+func FormatTitle(t Task) string {
+	var parts []string
+	if t.Priority != "" {
+		parts = append(parts, "("+t.Priority+")")
+	}
+	parts = append(parts, t.Title)
+	for _, project := range t.Projects {
+		parts = append(parts, "+"+project)
+	}
+	for _, context := range t.Contexts {
+		parts = append(parts, "@"+context)
+	}
+	if t.Estimate > 0 {
+		parts = append(parts, fmt.Sprintf("~%d", t.Estimate))
+	}
+	return strings.Join(parts, " ")
+}
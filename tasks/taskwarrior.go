@@ -0,0 +1,504 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// taskwarriorTimeout bounds one run of the task binary
+const taskwarriorTimeout = 10 * time.Second
+
+// taskwarriorRefresh is how long the exported list is used before it is
+// exported again in the background, to pick up changes made with task
+const taskwarriorRefresh = 10 * time.Second
+
+// taskwarriorRC is passed to every run: no prompts or chatter, and the
+// pomodoro counts and estimates as numeric UDAs so they work without any
+// .taskrc changes
+var taskwarriorRC = []string{
+	"rc.confirmation=off",
+	"rc.verbose=nothing",
+	"rc.uda.pomo.type=numeric",
+	"rc.uda.pomo.label=Pomodoros",
+	"rc.uda.est.type=numeric",
+	"rc.uda.est.label=Estimate",
+}
+
+// taskwarriorQueue is how many changes may wait for the task command
+const taskwarriorQueue = 64
+
+// createdUUID finds the new task in the output of `task add`
+var createdUUID = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+
+// Taskwarrior lists the pending tasks from Taskwarrior and updates them
+// through the task command. Changes are applied to the list at once and run
+// in order on a background goroutine, so the timer never waits for task;
+// their failures are reported by TakeError.
+type Taskwarrior struct {
+	binary     string
+	statePath  string // Remembers the active task
+	tasks      []Task
+	exported   time.Time
+	refreshing bool
+	pending    int // Changes queued or running, an export would undo them
+	queue      chan []string
+	done       chan struct{}
+	activeID   string
+	lastErr    error
+	mu         sync.Mutex
+}
+
+// NewTaskwarrior uses the task binary on PATH. The active task, a UUID, is
+// saved in statePath.
+func NewTaskwarrior(statePath string) (*Taskwarrior, error) {
+	binary, err := exec.LookPath("task")
+	if err != nil {
+		return nil, fmt.Errorf("taskwarrior: task not found on PATH")
+	}
+	tw := &Taskwarrior{
+		binary:    binary,
+		statePath: statePath,
+		queue:     make(chan []string, taskwarriorQueue),
+		done:      make(chan struct{}),
+	}
+	if data, err := os.ReadFile(statePath); err == nil {
+		tw.activeID = strings.TrimSpace(string(data))
+	}
+	if err := tw.refresh(); err != nil {
+		return nil, err
+	}
+	go tw.work()
+	return tw, nil
+}
+
+// Close waits for the queued changes
+func (tw *Taskwarrior) Close() {
+	close(tw.queue)
+	<-tw.done
+}
+
+// TakeError returns the last failure of the task command and clears it
+func (tw *Taskwarrior) TakeError() error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	err := tw.lastErr
+	tw.lastErr = nil
+	return err
+}
+
+// List returns the pending tasks by urgency, as `task next` does
+func (tw *Taskwarrior) List() []Task {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.refreshIfStaleLocked()
+
+	list := make([]Task, len(tw.tasks))
+	copy(list, tw.tasks)
+	return list
+}
+
+// Add runs `task add` and waits for it, to learn the new task's UUID.
+// input is Taskwarrior syntax, e.g. "Fix login project:web +bug", plus
+// "(A)" to "(C)" for the priority and a trailing "~N" estimate.
+func (tw *Taskwarrior) Add(input string) (Task, error) {
+	args, err := taskwarriorArgs(input)
+	if err != nil {
+		return Task{}, err
+	}
+	out, err := tw.run(append([]string{"rc.verbose=new-uuid", "add"}, args...)...)
+	if err != nil {
+		return Task{}, err
+	}
+	uuid := createdUUID.FindString(out)
+	if uuid == "" {
+		return Task{}, fmt.Errorf("taskwarrior: added task not found")
+	}
+
+	// Listed last until the next export sorts it by urgency
+	t := Task{ID: uuid, Created: time.Now()}
+	applyArgs(&t, args)
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.tasks = append(tw.tasks, t)
+	tw.exported = time.Time{}
+	return t, nil
+}
+
+// Edit runs `task modify`, replacing the description and clearing the
+// project, priority, tags and estimate left out of input
+func (tw *Taskwarrior) Edit(id, input string) error {
+	args, err := taskwarriorArgs(input)
+	if err != nil {
+		return err
+	}
+	old, ok := tw.find(id)
+	if !ok {
+		return fmt.Errorf("no task %q", id)
+	}
+
+	given := make(map[string]bool)
+	for _, arg := range args {
+		if key, _, ok := strings.Cut(arg, ":"); ok {
+			given[key] = true
+		}
+		given[arg] = true
+	}
+	for _, key := range []string{"project", "priority", "est"} {
+		if !given[key] {
+			args = append(args, key+":")
+		}
+	}
+	for _, tag := range old.Tags {
+		if !given["+"+tag] {
+			args = append(args, "-"+tag)
+		}
+	}
+	return tw.modify(id, append([]string{"modify"}, args...)...)
+}
+
+// SetEstimate sets the est UDA, clearing it for 0
+func (tw *Taskwarrior) SetEstimate(id string, estimate int) error {
+	value := ""
+	if estimate > 0 {
+		value = strconv.Itoa(estimate)
+	}
+	return tw.modify(id, "modify", "est:"+value)
+}
+
+// SetDone runs `task done`, or sets a completed task pending again. Only
+// pending tasks are listed, so a completed task leaves the list.
+func (tw *Taskwarrior) SetDone(id string, done bool) error {
+	if !done {
+		return tw.modify(id, "modify", "status:pending")
+	}
+	if err := tw.modify(id, "done"); err != nil {
+		return err
+	}
+
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.activeID == id {
+		return tw.setActiveLocked("")
+	}
+	return nil
+}
+
+// AddPomodoro counts a completed focus session in the pomo UDA
+func (tw *Taskwarrior) AddPomodoro(id string) error {
+	t, ok := tw.find(id)
+	if !ok {
+		return fmt.Errorf("no task %q", id)
+	}
+	return tw.modify(id, "modify", "pomo:"+strconv.Itoa(t.Pomodoros+1))
+}
+
+// SetActive selects the task focus sessions count towards, "" for none
+func (tw *Taskwarrior) SetActive(id string) error {
+	if id != "" {
+		if _, ok := tw.find(id); !ok {
+			return fmt.Errorf("no task %q", id)
+		}
+	}
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.setActiveLocked(id)
+}
+
+// Active returns the active task, while it is pending
+func (tw *Taskwarrior) Active() (Task, bool) {
+	tw.mu.Lock()
+	id := tw.activeID
+	tw.mu.Unlock()
+	if id == "" {
+		return Task{}, false
+	}
+	return tw.find(id)
+}
+
+// Format turns t back into Taskwarrior syntax for editing
+func (tw *Taskwarrior) Format(t Task) string {
+	var parts []string
+	if t.Priority != "" {
+		parts = append(parts, "("+t.Priority+")")
+	}
+	parts = append(parts, t.Title)
+	for _, project := range t.Projects {
+		parts = append(parts, "project:"+project)
+	}
+	for _, tag := range t.Tags {
+		parts = append(parts, "+"+tag)
+	}
+	if t.Estimate > 0 {
+		parts = append(parts, fmt.Sprintf("~%d", t.Estimate))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (tw *Taskwarrior) setActiveLocked(id string) error {
+	tw.activeID = id
+	if err := os.WriteFile(tw.statePath, []byte(id+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to save active task: %w", err)
+	}
+	return nil
+}
+
+func (tw *Taskwarrior) find(id string) (Task, bool) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.refreshIfStaleLocked()
+
+	for _, t := range tw.tasks {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Task{}, false
+}
+
+// modify queues a command on one task, "modify" or "done", and applies it
+// to the list until the queue is done and the list is exported again
+func (tw *Taskwarrior) modify(id string, args ...string) error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	select {
+	case tw.queue <- append([]string{id}, args...):
+	default:
+		return fmt.Errorf("taskwarrior: too many changes queued, try again")
+	}
+	tw.pending++
+
+	for i, t := range tw.tasks {
+		if t.ID != id {
+			continue
+		}
+		if args[0] == "done" {
+			t.Done = true
+		} else {
+			applyArgs(&t, args[1:])
+		}
+		if t.Done {
+			// Only pending tasks are listed
+			tw.tasks = append(tw.tasks[:i:i], tw.tasks[i+1:]...)
+		} else {
+			tw.tasks[i] = t
+		}
+		break
+	}
+	return nil
+}
+
+// work runs the queued changes, exporting the list once they are all done
+func (tw *Taskwarrior) work() {
+	defer close(tw.done)
+	for args := range tw.queue {
+		_, err := tw.run(args...)
+		tw.mu.Lock()
+		if err != nil {
+			tw.lastErr = err
+		}
+		tw.pending--
+		idle := tw.pending == 0
+		tw.mu.Unlock()
+
+		if idle {
+			if err := tw.refresh(); err != nil {
+				tw.mu.Lock()
+				tw.lastErr = err
+				tw.mu.Unlock()
+			}
+		}
+	}
+}
+
+// refreshIfStaleLocked exports the list again in the background once it is
+// old; tw.mu must be held
+func (tw *Taskwarrior) refreshIfStaleLocked() {
+	if tw.refreshing || time.Since(tw.exported) < taskwarriorRefresh {
+		return
+	}
+	tw.refreshing = true
+	go func() {
+		err := tw.refresh()
+		tw.mu.Lock()
+		tw.refreshing = false
+		if err != nil {
+			tw.lastErr = err
+		}
+		tw.mu.Unlock()
+	}()
+}
+
+// refresh exports the pending tasks. The export is dropped while changes
+// are queued, the queue exports again once they have run.
+func (tw *Taskwarrior) refresh() error {
+	out, err := tw.run("status:pending", "export")
+	if err != nil {
+		return err
+	}
+	var exported []taskwarriorTask
+	if err := json.Unmarshal([]byte(out), &exported); err != nil {
+		return fmt.Errorf("taskwarrior: failed to parse task export: %w", err)
+	}
+	sort.SliceStable(exported, func(i, j int) bool {
+		return exported[i].Urgency > exported[j].Urgency
+	})
+
+	list := make([]Task, 0, len(exported))
+	for _, t := range exported {
+		list = append(list, t.Task())
+	}
+
+	tw.mu.Lock()
+	if tw.pending == 0 {
+		tw.tasks = list
+		tw.exported = time.Now()
+	}
+	tw.mu.Unlock()
+	return nil
+}
+
+func (tw *Taskwarrior) run(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), taskwarriorTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, tw.binary, append(append([]string(nil), taskwarriorRC...), args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("taskwarrior: %s", msg)
+		}
+		return "", fmt.Errorf("taskwarrior: %w", err)
+	}
+	return stdout.String(), nil
+}
+
+// taskwarriorTask is a task as exported by `task export`
+type taskwarriorTask struct {
+	UUID        string          `json:"uuid"`
+	Description string          `json:"description"`
+	Project     string          `json:"project"`
+	Tags        []string        `json:"tags"`
+	Priority    string          `json:"priority"` // "H", "M" or "L"
+	Status      string          `json:"status"`
+	Entry       string          `json:"entry"`
+	End         string          `json:"end"`
+	Urgency     float64         `json:"urgency"`
+	Pomo        json.RawMessage `json:"pomo"`
+	Est         json.RawMessage `json:"est"`
+}
+
+// taskwarriorPriorities maps Taskwarrior's priorities to the task panel's
+var taskwarriorPriorities = map[string]string{"H": "A", "M": "B", "L": "C"}
+
+// Task converts an exported task for the task panel
+func (t taskwarriorTask) Task() Task {
+	task := Task{
+		ID:        t.UUID,
+		Title:     t.Description,
+		Priority:  taskwarriorPriorities[t.Priority],
+		Tags:      t.Tags,
+		Estimate:  udaInt(t.Est),
+		Pomodoros: udaInt(t.Pomo),
+		Done:      t.Status == "completed",
+	}
+	if t.Project != "" {
+		task.Projects = []string{t.Project}
+	}
+	if entry, err := time.Parse("20060102T150405Z", t.Entry); err == nil {
+		task.Created = entry.Local()
+	}
+	if end, err := time.Parse("20060102T150405Z", t.End); err == nil {
+		task.Completed = end.Local()
+	}
+	return task
+}
+
+// udaInt reads a numeric UDA, exported as a number or, without a UDA
+// definition, as a string
+func udaInt(raw json.RawMessage) int {
+	text := strings.Trim(string(raw), `"`)
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// applyArgs updates t as `task modify` with args will, for the list shown
+// until the next export
+func applyArgs(t *Task, args []string) {
+	var title []string
+	tags := append([]string(nil), t.Tags...)
+	for _, arg := range args {
+		key, value, _ := strings.Cut(arg, ":")
+		switch {
+		case key == "project" && value != arg:
+			t.Projects = nil
+			if value != "" {
+				t.Projects = []string{value}
+			}
+		case key == "priority" && value != arg:
+			t.Priority = taskwarriorPriorities[value]
+		case key == "est" && value != arg:
+			t.Estimate, _ = strconv.Atoi(value)
+		case key == "pomo" && value != arg:
+			t.Pomodoros, _ = strconv.Atoi(value)
+		case key == "status" && value != arg:
+			t.Done = value == "completed"
+		case len(arg) > 1 && arg[0] == '+':
+			if !slices.Contains(tags, arg[1:]) {
+				tags = append(tags, arg[1:])
+			}
+		case len(arg) > 1 && arg[0] == '-':
+			tags = slices.DeleteFunc(tags, func(tag string) bool { return tag == arg[1:] })
+		default:
+			title = append(title, arg)
+		}
+	}
+	t.Tags = tags
+	if len(title) > 0 {
+		t.Title = strings.Join(title, " ")
+	}
+}
+
+// taskwarriorArgs turns input into arguments for add and modify
+func taskwarriorArgs(input string) ([]string, error) {
+	title, estimate := ParseTitle(input)
+	words := strings.Fields(title)
+	var args []string
+	if len(words) > 0 && isPriority(words[0]) {
+		priority := ""
+		for tw, p := range taskwarriorPriorities {
+			if p == words[0][1:2] {
+				priority = tw
+			}
+		}
+		if priority == "" {
+			return nil, fmt.Errorf("taskwarrior priorities are (A), (B) and (C)")
+		}
+		args = append(args, "priority:"+priority)
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("task title is empty")
+	}
+	args = append(args, words...)
+	if estimate > 0 {
+		args = append(args, "est:"+strconv.Itoa(estimate))
+	}
+	return args, nil
+}
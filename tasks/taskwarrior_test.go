@@ -0,0 +1,220 @@
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const exported = `[
+{"uuid":"11111111-1111-1111-1111-111111111111","description":"Write report","project":"work","tags":["writing","q3"],"priority":"H","status":"pending","entry":"20240102T030405Z","urgency":3.5,"pomo":2,"est":"4"},
+{"uuid":"22222222-2222-2222-2222-222222222222","description":"Water plants","status":"pending","urgency":9.1}
+]`
+
+// fakeTask puts a task script on PATH that logs its arguments, one line
+// per run, answers export with exported and add with a new UUID. Runs of
+// command sleep for delay, and fail when fail is set.
+func fakeTask(t *testing.T, command string, delay time.Duration, fail bool) (log string) {
+	t.Helper()
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("cat not found")
+	}
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not found")
+	}
+
+	dir := t.TempDir()
+	log = filepath.Join(dir, "log")
+	export := filepath.Join(dir, "export.json")
+	if err := os.WriteFile(export, []byte(exported), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := fmt.Sprintf(`#!/bin/sh
+for arg in "$@"; do printf '%%s|' "$arg"; done >> %[1]q
+printf '\n' >> %[1]q
+for arg in "$@"; do
+	case "$arg" in
+	export) exec %[2]s %[3]q ;;
+	add) echo "Created task 33333333-3333-3333-3333-333333333333." ; exit 0 ;;
+	%[4]s)
+		%[5]s %[6]f
+		if [ %[7]t = true ]; then echo "task broke" >&2; exit 1; fi ;;
+	esac
+done
+`, log, cat, export, command, sleep, delay.Seconds(), fail)
+	if err := os.WriteFile(filepath.Join(dir, "task"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	return log
+}
+
+// runs returns the logged runs of task without the rc overrides
+func runs(t *testing.T, log string) []string {
+	t.Helper()
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := strings.Join(taskwarriorRC, "|") + "|"
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if !strings.HasPrefix(line, rc) {
+			t.Errorf("run without the rc overrides: %s", line)
+		}
+		lines = append(lines, strings.TrimSuffix(strings.TrimPrefix(line, rc), "|"))
+	}
+	return lines
+}
+
+func newTaskwarrior(t *testing.T) *Taskwarrior {
+	t.Helper()
+	tw, err := NewTaskwarrior(filepath.Join(t.TempDir(), "active"))
+	if err != nil {
+		t.Fatalf("NewTaskwarrior: %v", err)
+	}
+	return tw
+}
+
+func TestTaskwarriorExport(t *testing.T) {
+	log := fakeTask(t, "none", 0, false)
+	tw := newTaskwarrior(t)
+	defer tw.Close()
+
+	list := tw.List()
+	if len(list) != 2 {
+		t.Fatalf("%d tasks, want 2", len(list))
+	}
+	if list[0].Title != "Water plants" {
+		t.Errorf("first task %q, want the most urgent", list[0].Title)
+	}
+	got := list[1]
+	want := Task{
+		ID:        "11111111-1111-1111-1111-111111111111",
+		Title:     "Write report",
+		Priority:  "A",
+		Projects:  []string{"work"},
+		Tags:      []string{"writing", "q3"},
+		Estimate:  4,
+		Pomodoros: 2,
+		Created:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Local(),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("task = %+v\nwant %+v", got, want)
+	}
+	if r := runs(t, log); !reflect.DeepEqual(r, []string{"status:pending|export"}) {
+		t.Errorf("runs = %q", r)
+	}
+}
+
+func TestTaskwarriorChangesQueued(t *testing.T) {
+	log := fakeTask(t, "modify", 300*time.Millisecond, false)
+	tw := newTaskwarrior(t)
+	id := "11111111-1111-1111-1111-111111111111"
+
+	began := time.Now()
+	for _, change := range []func() error{
+		func() error { return tw.AddPomodoro(id) },
+		func() error { return tw.AddPomodoro(id) },
+		func() error { return tw.SetEstimate(id, 5) },
+		func() error { return tw.Edit(id, "(B) Write the report +writing ~6") },
+	} {
+		if err := change(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if waited := time.Since(began); waited > 200*time.Millisecond {
+		t.Errorf("changes waited %s for task", waited)
+	}
+
+	// Shown before task has run
+	got, ok := tw.find(id)
+	if !ok {
+		t.Fatal("task left the list")
+	}
+	if got.Pomodoros != 4 || got.Estimate != 6 || got.Title != "Write the report" || got.Priority != "B" ||
+		got.Projects != nil || !reflect.DeepEqual(got.Tags, []string{"writing"}) {
+		t.Errorf("task = %+v", got)
+	}
+
+	tw.Close()
+	if err := tw.TakeError(); err != nil {
+		t.Errorf("TakeError = %v", err)
+	}
+	want := []string{
+		"status:pending|export",
+		id + "|modify|pomo:3",
+		id + "|modify|pomo:4",
+		id + "|modify|est:5",
+		id + "|modify|priority:M|Write|the|report|+writing|est:6|project:|-q3",
+		"status:pending|export",
+	}
+	if r := runs(t, log); !reflect.DeepEqual(r, want) {
+		t.Errorf("runs = %q\nwant %q", r, want)
+	}
+}
+
+func TestTaskwarriorDone(t *testing.T) {
+	log := fakeTask(t, "done", 0, false)
+	tw := newTaskwarrior(t)
+	id := "22222222-2222-2222-2222-222222222222"
+	if err := tw.SetActive(id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tw.SetDone(id, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tw.find(id); ok {
+		t.Error("a completed task is still listed")
+	}
+	if _, ok := tw.Active(); ok {
+		t.Error("a completed task is still active")
+	}
+	tw.Close()
+	if r := runs(t, log); len(r) < 2 || r[1] != id+"|done" {
+		t.Errorf("runs = %q, want done", r)
+	}
+}
+
+func TestTaskwarriorAdd(t *testing.T) {
+	log := fakeTask(t, "none", 0, false)
+	tw := newTaskwarrior(t)
+	defer tw.Close()
+
+	added, err := tw.Add("(C) Call the bank project:home +phone ~1")
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if added.ID != "33333333-3333-3333-3333-333333333333" || added.Title != "Call the bank" ||
+		added.Priority != "C" || added.Estimate != 1 || !reflect.DeepEqual(added.Projects, []string{"home"}) {
+		t.Errorf("added = %+v", added)
+	}
+	if _, ok := tw.find(added.ID); !ok {
+		t.Error("added task not listed")
+	}
+	want := "rc.verbose=new-uuid|add|priority:L|Call|the|bank|project:home|+phone|est:1"
+	if r := runs(t, log); len(r) < 2 || r[1] != want {
+		t.Errorf("runs = %q, want %q second", r, want)
+	}
+}
+
+func TestTaskwarriorFailureReported(t *testing.T) {
+	fakeTask(t, "modify", 0, true)
+	tw := newTaskwarrior(t)
+
+	if err := tw.AddPomodoro("11111111-1111-1111-1111-111111111111"); err != nil {
+		t.Fatalf("AddPomodoro: %v", err)
+	}
+	tw.Close()
+	if err := tw.TakeError(); err == nil || !strings.Contains(err.Error(), "task broke") {
+		t.Errorf("TakeError = %v, want the failure", err)
+	}
+}
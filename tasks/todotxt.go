@@ -0,0 +1,584 @@
+package tasks
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// todo.txt key:value extensions written by zoneout
+const (
+	pomoKey     = "pomo" // Pomodoros spent on the task
+	estimateKey = "est"  // Pomodoros the task is expected to take
+	priorityKey = "pri"  // Priority of a completed task, as other tools do
+)
+
+const todoDateFormat = "2006-01-02"
+
+// TodoTxt is a task list kept in a todo.txt file
+// (https://github.com/todotxt/todo.txt). Lines zoneout doesn't change are
+// left exactly as they are, and the file is re-read whenever another tool
+// changes it.
+type TodoTxt struct {
+	path      string
+	statePath string // Remembers the active task
+	tasks     []Task
+	lines     []int // Line number of each task
+	crlf      bool  // The file has Windows line endings
+	activeID  string
+	modTime   time.Time
+	size      int64
+	lastErr   error
+	mu        sync.Mutex
+}
+
+// NewTodoTxt reads the todo.txt file at path. The active task is saved in
+// statePath, since todo.txt has no place for it.
+func NewTodoTxt(path, statePath string) *TodoTxt {
+	t := &TodoTxt{path: path, statePath: statePath}
+	if data, err := os.ReadFile(statePath); err == nil {
+		t.activeID = strings.TrimSpace(string(data))
+	}
+	t.mu.Lock()
+	t.reloadLocked()
+	t.mu.Unlock()
+	return t
+}
+
+// Path is the todo.txt file
+func (t *TodoTxt) Path() string {
+	return t.path
+}
+
+// TakeError returns the last failure to read or write the file and clears it
+func (t *TodoTxt) TakeError() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	err := t.lastErr
+	t.lastErr = nil
+	return err
+}
+
+// List returns the tasks, open ones first, by priority and then in file
+// order
+func (t *TodoTxt) List() []Task {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reloadLocked()
+
+	list := make([]Task, len(t.tasks))
+	copy(list, t.tasks)
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Done != list[j].Done {
+			return !list[i].Done
+		}
+		return priorityRank(list[i].Priority) < priorityRank(list[j].Priority)
+	})
+	return list
+}
+
+// Add appends a task to the file, dated today. input is a todo.txt
+// description, optionally starting with a priority such as "(A)" and
+// ending with a "~N" estimate.
+func (t *TodoTxt) Add(input string) (Task, error) {
+	item := parseInput(input)
+	if len(descriptionText(item.Words)) == 0 {
+		return Task{}, fmt.Errorf("task title is empty")
+	}
+	item.Created = time.Now().Format(todoDateFormat)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines, err := t.readLinesLocked()
+	if err != nil {
+		return Task{}, err
+	}
+	lines = append(lines, item.String())
+	if err := t.writeLinesLocked(lines); err != nil {
+		return Task{}, err
+	}
+	if n := len(t.tasks); n > 0 && t.lines[n-1] == len(lines)-1 {
+		return t.tasks[n-1], nil // With its ID told apart from duplicates
+	}
+	return item.Task(), nil
+}
+
+// Edit replaces a task's priority, description and estimate, given as
+// for Add. Its dates and other key:value extensions are kept, unless
+// input gives them again.
+func (t *TodoTxt) Edit(id, input string) error {
+	edited := parseInput(input)
+	if len(descriptionText(edited.Words)) == 0 {
+		return fmt.Errorf("task title is empty")
+	}
+
+	n, err := t.updateLine(id, func(item *todoItem) {
+		words := edited.Words
+		for _, word := range item.Words {
+			k, _, ok := keyValue(word)
+			if !ok || k == estimateKey || k == priorityKey {
+				continue
+			}
+			if _, retyped := edited.Tag(k); !retyped {
+				words = append(words, word)
+			}
+		}
+		item.Words = words
+		if item.Done {
+			// Completed tasks keep their priority as an extension
+			if edited.Priority != "" {
+				item.SetTag(priorityKey, edited.Priority)
+			}
+		} else {
+			item.Priority = edited.Priority
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// The ID follows the description, keep the task active
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.activeID != id {
+		return nil
+	}
+	for i, line := range t.lines {
+		if line == n && t.tasks[i].ID != id {
+			return t.setActiveLocked(t.tasks[i].ID)
+		}
+	}
+	return nil
+}
+
+// SetEstimate sets the est:N extension, removing it for 0
+func (t *TodoTxt) SetEstimate(id string, estimate int) error {
+	return t.update(id, func(item *todoItem) {
+		if estimate <= 0 {
+			item.RemoveTag(estimateKey)
+			return
+		}
+		item.SetTag(estimateKey, strconv.Itoa(estimate))
+	})
+}
+
+// SetDone writes the "x date" completion marker, or removes it. The
+// priority is kept as pri:X while the task is complete.
+func (t *TodoTxt) SetDone(id string, done bool) error {
+	err := t.update(id, func(item *todoItem) {
+		if item.Done == done {
+			return
+		}
+		item.Done = done
+		if done {
+			item.Completed = time.Now().Format(todoDateFormat)
+			if item.Priority != "" {
+				item.SetTag(priorityKey, item.Priority)
+				item.Priority = ""
+			}
+			return
+		}
+		item.Completed = ""
+		if pri, ok := item.Tag(priorityKey); ok && len(pri) == 1 {
+			item.Priority = pri
+			item.RemoveTag(priorityKey)
+		}
+	})
+	if err != nil || !done {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.activeID == id {
+		return t.setActiveLocked("")
+	}
+	return nil
+}
+
+// AddPomodoro counts a completed focus session in the pomo:N extension
+func (t *TodoTxt) AddPomodoro(id string) error {
+	return t.update(id, func(item *todoItem) {
+		n, _ := strconv.Atoi(item.TagOr(pomoKey, "0"))
+		item.SetTag(pomoKey, strconv.Itoa(n+1))
+	})
+}
+
+// SetActive selects the task focus sessions count towards, "" for none
+func (t *TodoTxt) SetActive(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reloadLocked()
+
+	if id != "" && t.findLocked(id) < 0 {
+		return fmt.Errorf("no task %q", id)
+	}
+	return t.setActiveLocked(id)
+}
+
+// Active returns the active task, if it is still in the file
+func (t *TodoTxt) Active() (Task, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reloadLocked()
+
+	if i := t.findLocked(t.activeID); i >= 0 {
+		return t.tasks[i], true
+	}
+	return Task{}, false
+}
+
+func (t *TodoTxt) setActiveLocked(id string) error {
+	t.activeID = id
+	if err := os.WriteFile(t.statePath, []byte(id+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to save active task: %w", err)
+	}
+	return nil
+}
+
+func (t *TodoTxt) findLocked(id string) int {
+	if id == "" {
+		return -1
+	}
+	for i, task := range t.tasks {
+		if task.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// update rewrites the line of task id with change applied
+func (t *TodoTxt) update(id string, change func(item *todoItem)) error {
+	_, err := t.updateLine(id, change)
+	return err
+}
+
+// updateLine is update, also returning the number of the line changed
+func (t *TodoTxt) updateLine(id string, change func(item *todoItem)) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reloadLocked()
+
+	if t.findLocked(id) < 0 {
+		return 0, fmt.Errorf("no task %q", id)
+	}
+	// Find the line again in what is read now, in case another tool
+	// changed the file since it was listed
+	lines, err := t.readLinesLocked()
+	if err != nil {
+		return 0, err
+	}
+	tasks, numbers := parseTasks(lines)
+	n := -1
+	for i, task := range tasks {
+		if task.ID == id {
+			n = numbers[i]
+			break
+		}
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%s changed, try again", t.path)
+	}
+	item := parseTodoLine(lines[n])
+	change(&item)
+	lines[n] = item.String()
+	return n, t.writeLinesLocked(lines)
+}
+
+// reloadLocked re-reads the file if it changed since it was last read
+func (t *TodoTxt) reloadLocked() {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			t.tasks, t.lines = nil, nil
+			return
+		}
+		t.lastErr = fmt.Errorf("failed to read %s: %w", t.path, err)
+		return
+	}
+	if info.ModTime().Equal(t.modTime) && info.Size() == t.size && t.tasks != nil {
+		return
+	}
+
+	lines, err := t.readLinesLocked()
+	if err != nil {
+		t.lastErr = err
+		return
+	}
+	t.tasks, t.lines = parseTasks(lines)
+	t.modTime = info.ModTime()
+	t.size = info.Size()
+}
+
+// parseTasks parses the non-blank lines of a todo.txt file, returning the
+// number of the line each task is on. Lines with the same description get
+// "-2", "-3"... after their ID in file order, so each has its own.
+func parseTasks(lines []string) ([]Task, []int) {
+	tasks := make([]Task, 0, len(lines))
+	numbers := make([]int, 0, len(lines))
+	seen := make(map[string]int)
+	for n, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		task := parseTodoLine(line).Task()
+		seen[task.ID]++
+		if k := seen[task.ID]; k > 1 {
+			task.ID += "-" + strconv.Itoa(k)
+		}
+		tasks = append(tasks, task)
+		numbers = append(numbers, n)
+	}
+	return tasks, numbers
+}
+
+func (t *TodoTxt) readLinesLocked() ([]string, error) {
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", t.path, err)
+	}
+	t.crlf = strings.Contains(string(data), "\r\n")
+	text := strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
+// writeLinesLocked atomically replaces the file, keeping its permissions,
+// and forces the next access to re-read it
+func (t *TodoTxt) writeLinesLocked(lines []string) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(t.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	newline := "\n"
+	if t.crlf {
+		newline = "\r\n"
+	}
+	data := strings.Join(lines, newline) + newline
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), ".todo.txt.*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", t.path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", t.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", t.path, err)
+	}
+	os.Chmod(tmp.Name(), mode)
+	if err := os.Rename(tmp.Name(), t.path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", t.path, err)
+	}
+
+	t.tasks = nil
+	t.reloadLocked()
+	return nil
+}
+
+// todoItem is one parsed todo.txt line
+type todoItem struct {
+	Done      bool
+	Completed string // Completion date, if done
+	Priority  string // "A" to "Z", empty for none
+	Created   string
+	Words     []string // Description: text, +projects, @contexts and key:value pairs
+}
+
+func parseTodoLine(line string) todoItem {
+	var item todoItem
+	words := strings.Fields(line)
+	if len(words) > 0 && words[0] == "x" {
+		item.Done = true
+		words = words[1:]
+		if len(words) > 0 && isTodoDate(words[0]) {
+			item.Completed = words[0]
+			words = words[1:]
+		}
+	}
+	if len(words) > 0 && isPriority(words[0]) {
+		item.Priority = words[0][1:2]
+		words = words[1:]
+	}
+	if len(words) > 0 && isTodoDate(words[0]) {
+		item.Created = words[0]
+		words = words[1:]
+	}
+	item.Words = words
+	return item
+}
+
+// String formats the item as a todo.txt line
+func (item todoItem) String() string {
+	var parts []string
+	if item.Done {
+		parts = append(parts, "x")
+		if item.Completed != "" {
+			parts = append(parts, item.Completed)
+		}
+	}
+	if item.Priority != "" {
+		parts = append(parts, "("+item.Priority+")")
+	}
+	if item.Created != "" {
+		parts = append(parts, item.Created)
+	}
+	return strings.Join(append(parts, item.Words...), " ")
+}
+
+// Task converts the item for the task panel. The ID is derived from the
+// description without key:value pairs, so it survives pomodoro counts and
+// completion. parseTasks tells duplicate descriptions apart.
+func (item todoItem) Task() Task {
+	task := Task{
+		Title: strings.Join(descriptionText(item.Words), " "),
+		Done:  item.Done,
+	}
+	for _, word := range item.Words {
+		switch {
+		case isTag(word, '+'):
+			task.Projects = append(task.Projects, word[1:])
+		case isTag(word, '@'):
+			task.Contexts = append(task.Contexts, word[1:])
+		}
+	}
+	task.Estimate, _ = strconv.Atoi(item.TagOr(estimateKey, "0"))
+	task.Pomodoros, _ = strconv.Atoi(item.TagOr(pomoKey, "0"))
+	if !item.Done {
+		task.Priority = item.Priority
+	} else if pri, ok := item.Tag(priorityKey); ok {
+		task.Priority = pri
+	}
+	if created, err := time.ParseInLocation(todoDateFormat, item.Created, time.Local); err == nil {
+		task.Created = created
+	}
+	if completed, err := time.ParseInLocation(todoDateFormat, item.Completed, time.Local); err == nil {
+		task.Completed = completed
+	}
+
+	var key []string
+	for _, word := range item.Words {
+		if _, _, ok := keyValue(word); !ok {
+			key = append(key, word)
+		}
+	}
+	sum := sha1.Sum([]byte(strings.Join(key, " ")))
+	task.ID = hex.EncodeToString(sum[:6])
+	return task
+}
+
+// Tag returns the value of a key:value extension
+func (item todoItem) Tag(key string) (string, bool) {
+	for _, word := range item.Words {
+		if k, v, ok := keyValue(word); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func (item todoItem) TagOr(key, fallback string) string {
+	if v, ok := item.Tag(key); ok {
+		return v
+	}
+	return fallback
+}
+
+// SetTag replaces a key:value extension in place, or appends it
+func (item *todoItem) SetTag(key, value string) {
+	for i, word := range item.Words {
+		if k, _, ok := keyValue(word); ok && k == key {
+			item.Words[i] = key + ":" + value
+			return
+		}
+	}
+	item.Words = append(item.Words, key+":"+value)
+}
+
+func (item *todoItem) RemoveTag(key string) {
+	words := item.Words[:0]
+	for _, word := range item.Words {
+		if k, _, ok := keyValue(word); !ok || k != key {
+			words = append(words, word)
+		}
+	}
+	item.Words = words
+}
+
+// parseInput turns what the user typed into a todo.txt item, with a
+// trailing "~N" estimate as est:N
+func parseInput(input string) todoItem {
+	title, estimate := ParseTitle(input)
+	item := todoItem{Words: strings.Fields(title)}
+	if len(item.Words) > 0 && isPriority(item.Words[0]) {
+		item.Priority = item.Words[0][1:2]
+		item.Words = item.Words[1:]
+	}
+	if estimate > 0 {
+		item.SetTag(estimateKey, strconv.Itoa(estimate))
+	}
+	return item
+}
+
+// descriptionText is the description without projects, contexts and
+// key:value pairs
+func descriptionText(words []string) []string {
+	var text []string
+	for _, word := range words {
+		if isTag(word, '+') || isTag(word, '@') {
+			continue
+		}
+		if _, _, ok := keyValue(word); ok {
+			continue
+		}
+		text = append(text, word)
+	}
+	return text
+}
+
+// keyValue splits a key:value extension. URLs such as https://example.com
+// are not extensions.
+func keyValue(word string) (key, value string, ok bool) {
+	key, value, ok = strings.Cut(word, ":")
+	if !ok || key == "" || value == "" || strings.HasPrefix(value, "//") || strings.Contains(value, ":") {
+		return "", "", false
+	}
+	return key, value, true
+}
+
+func isTag(word string, prefix byte) bool {
+	return len(word) > 1 && word[0] == prefix
+}
+
+func isPriority(word string) bool {
+	return len(word) == 3 && word[0] == '(' && word[2] == ')' && word[1] >= 'A' && word[1] <= 'Z'
+}
+
+func isTodoDate(word string) bool {
+	_, err := time.Parse(todoDateFormat, word)
+	return err == nil
+}
+
+// priorityRank sorts A first and tasks without a priority last
+func priorityRank(priority string) int {
+	if priority == "" {
+		return 'Z' + 1
+	}
+	return int(priority[0])
+}
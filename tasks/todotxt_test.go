@@ -0,0 +1,245 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTodoTxt writes lines to a todo.txt file and opens it
+func newTodoTxt(t *testing.T, lines ...string) (*TodoTxt, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "todo.txt")
+	if len(lines) > 0 {
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewTodoTxt(path, filepath.Join(dir, "active")), path
+}
+
+// fileLines reads the todo.txt file back
+func fileLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func today() string {
+	return time.Now().Format(todoDateFormat)
+}
+
+func TestTodoLineRoundTrip(t *testing.T) {
+	for _, line := range []string{
+		"Call mom",
+		"(A) Call mom +family @phone",
+		"(B) 2026-10-01 Write report +work est:4 pomo:2",
+		"x 2026-10-02 2026-10-01 Write report pri:A",
+		"x 2026-10-02 Done without a creation date",
+		"Read https://example.com/post due:2026-10-20",
+		"xylophone lessons",
+		"(a) lowercase is not a priority",
+	} {
+		if got := parseTodoLine(line).String(); got != line {
+			t.Errorf("%q formats as %q", line, got)
+		}
+	}
+}
+
+func TestTodoLineTask(t *testing.T) {
+	tests := []struct {
+		line string
+		want Task
+	}{
+		{
+			line: "(A) 2026-10-01 Write report +work @desk est:4 pomo:2",
+			want: Task{
+				Title:     "Write report",
+				Priority:  "A",
+				Projects:  []string{"work"},
+				Contexts:  []string{"desk"},
+				Estimate:  4,
+				Pomodoros: 2,
+				Created:   time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local),
+			},
+		},
+		{
+			line: "x 2026-10-02 2026-10-01 Write report pri:B",
+			want: Task{
+				Title:     "Write report",
+				Priority:  "B",
+				Done:      true,
+				Created:   time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local),
+				Completed: time.Date(2026, 10, 2, 0, 0, 0, 0, time.Local),
+			},
+		},
+		{
+			line: "Read https://example.com/post",
+			want: Task{Title: "Read https://example.com/post"},
+		},
+	}
+	for _, tt := range tests {
+		got := parseTodoLine(tt.line).Task()
+		got.ID = ""
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q\n got %+v\nwant %+v", tt.line, got, tt.want)
+		}
+	}
+
+	// The ID survives counts, estimates and completion
+	a := parseTodoLine("(A) Write report +work").Task().ID
+	b := parseTodoLine("x 2026-10-02 Write report +work pomo:3 est:4 pri:A").Task().ID
+	if a != b {
+		t.Errorf("IDs %s and %s differ for the same task", a, b)
+	}
+}
+
+func TestTodoTxtDone(t *testing.T) {
+	todo, path := newTodoTxt(t, "(A) 2026-10-01 Write report +work", "Call mom")
+	id := todo.List()[0].ID
+	if err := todo.SetActive(id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := todo.SetDone(id, true); err != nil {
+		t.Fatalf("SetDone: %v", err)
+	}
+	want := []string{"x " + today() + " 2026-10-01 Write report +work pri:A", "Call mom"}
+	if got := fileLines(t, path); !reflect.DeepEqual(got, want) {
+		t.Errorf("file = %q\nwant %q", got, want)
+	}
+	if _, ok := todo.Active(); ok {
+		t.Error("a completed task is still active")
+	}
+	if list := todo.List(); list[0].Title != "Call mom" || !list[1].Done || list[1].Priority != "A" {
+		t.Errorf("list = %+v, want the open task first", list)
+	}
+
+	// Reopening restores the priority
+	if err := todo.SetDone(id, false); err != nil {
+		t.Fatalf("SetDone: %v", err)
+	}
+	want[0] = "(A) 2026-10-01 Write report +work"
+	if got := fileLines(t, path); !reflect.DeepEqual(got, want) {
+		t.Errorf("file = %q\nwant %q", got, want)
+	}
+}
+
+func TestTodoTxtPomodoros(t *testing.T) {
+	todo, path := newTodoTxt(t, "Write report due:2026-10-20")
+	id := todo.List()[0].ID
+	for i := 0; i < 2; i++ {
+		if err := todo.AddPomodoro(id); err != nil {
+			t.Fatalf("AddPomodoro: %v", err)
+		}
+	}
+	if err := todo.SetEstimate(id, 3); err != nil {
+		t.Fatalf("SetEstimate: %v", err)
+	}
+	want := []string{"Write report due:2026-10-20 pomo:2 est:3"}
+	if got := fileLines(t, path); !reflect.DeepEqual(got, want) {
+		t.Errorf("file = %q\nwant %q", got, want)
+	}
+	if got := todo.List()[0]; got.ID != id || got.Pomodoros != 2 || got.Estimate != 3 {
+		t.Errorf("task = %+v", got)
+	}
+
+	if err := todo.SetEstimate(id, 0); err != nil {
+		t.Fatalf("SetEstimate: %v", err)
+	}
+	if got := fileLines(t, path)[0]; got != "Write report due:2026-10-20 pomo:2" {
+		t.Errorf("line = %q after clearing the estimate", got)
+	}
+}
+
+func TestTodoTxtEdit(t *testing.T) {
+	todo, path := newTodoTxt(t, "(A) 2026-10-01 Write report +work due:2026-10-20 pomo:2 est:3")
+	id := todo.List()[0].ID
+	if err := todo.SetActive(id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := todo.Edit(id, "(B) Write the report +work due:2026-10-21 ~5"); err != nil {
+		t.Fatalf("Edit: %v", err)
+	}
+	want := []string{"(B) 2026-10-01 Write the report +work due:2026-10-21 est:5 pomo:2"}
+	if got := fileLines(t, path); !reflect.DeepEqual(got, want) {
+		t.Errorf("file = %q\nwant %q", got, want)
+	}
+	if active, ok := todo.Active(); !ok || active.Title != "Write the report" {
+		t.Errorf("active = %+v, %v, want the edited task", active, ok)
+	}
+
+	if err := todo.Edit(id, "  "); err == nil {
+		t.Error("Edit accepted an empty title")
+	}
+}
+
+func TestTodoTxtDuplicateLines(t *testing.T) {
+	todo, path := newTodoTxt(t, "Call bob", "Call bob", "", "Call bob")
+	list := todo.List()
+	if len(list) != 3 {
+		t.Fatalf("%d tasks, want 3", len(list))
+	}
+	ids := map[string]bool{}
+	for _, task := range list {
+		ids[task.ID] = true
+	}
+	if len(ids) != 3 {
+		t.Fatalf("IDs %v are not distinct", ids)
+	}
+
+	// Each change lands on its own line
+	if err := todo.AddPomodoro(list[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := todo.SetDone(list[2].ID, true); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Call bob", "Call bob pomo:1", "", "x " + today() + " Call bob"}
+	if got := fileLines(t, path); !reflect.DeepEqual(got, want) {
+		t.Errorf("file = %q\nwant %q", got, want)
+	}
+
+	added, err := todo.Add("Call bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids[added.ID] {
+		t.Errorf("added task reuses ID %s", added.ID)
+	}
+}
+
+func TestTodoTxtKeepsWindowsLineEndings(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "todo.txt")
+	if err := os.WriteFile(path, []byte("Call mom\r\nWrite report\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	todo := NewTodoTxt(path, filepath.Join(dir, "active"))
+	if _, err := todo.Add("Buy milk"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Call mom\r\nWrite report\r\n" + today() + " Buy milk\r\n"
+	if string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600 kept", info.Mode().Perm())
+	}
+}
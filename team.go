@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"zoneout/teamsync"
+)
+
+// runHost runs the timer and shares it with peers on the network
+func runHost(args []string, configDir string) int {
+	fs := flag.NewFlagSet("host", flag.ContinueOnError)
+	key := fs.String("key", "", "key peers must give to join, required unless ADDR is a loopback address")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: zoneout host -key KEY [ADDR]")
+		fmt.Fprintf(os.Stderr, "ADDR defaults to :%s, every interface\n", teamsync.DefaultPort)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	addr := ":" + teamsync.DefaultPort
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		addr = fs.Arg(0)
+	default:
+		fs.Usage()
+		return 2
+	}
+
+	return runStandalone(configDir, func(a *app, p *tea.Program) error {
+		host, err := teamsync.Listen(addr, *key, func(msg interface{}) { p.Send(msg) })
+		if err != nil {
+			return err
+		}
+		go host.Serve()
+		a.model.SetTeamHost(host)
+		a.closers = append([]func(){func() { host.Close() }}, a.closers...)
+		return nil
+	})
+}
+
+// runJoin runs a timer that follows a host started with `zoneout host`
+func runJoin(args []string, configDir string) int {
+	fs := flag.NewFlagSet("join", flag.ContinueOnError)
+	key := fs.String("key", "", "key the host asks for")
+	name := fs.String("name", defaultPeerName(), "name shown to the rest of the team")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: zoneout join [-key KEY] [-name NAME] HOST[:PORT]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	return runStandalone(configDir, func(a *app, p *tea.Program) error {
+		peer := teamsync.Join(fs.Arg(0), *name, *key, func(msg interface{}) { p.Send(msg) })
+		a.model.SetTeamPeer(peer)
+		a.closers = append([]func(){peer.Close}, a.closers...)
+		return nil
+	})
+}
+
+// defaultPeerName is user@hostname, or whichever part is known
+func defaultPeerName() string {
+	name := "peer"
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		name = u.Username
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		name += "@" + hostname
+	}
+	return name
+}
@@ -0,0 +1,261 @@
+package teamsync
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"zoneout/control"
+	"zoneout/models"
+)
+
+// peerCommands are the control commands peers may send
+var peerCommands = map[string]bool{
+	control.CommandStart:  true,
+	control.CommandPause:  true,
+	control.CommandResume: true,
+	control.CommandToggle: true,
+	control.CommandSkip:   true,
+	control.CommandReset:  true,
+}
+
+// Host accepts peers and keeps them on its timer
+type Host struct {
+	listener  net.Listener
+	key       string
+	send      func(msg interface{})
+	peers     map[*hostPeer]struct{}
+	last      *State // Last state broadcast
+	lastSent  time.Time
+	mu        sync.Mutex
+	closeOnce sync.Once
+}
+
+// hostPeer is one connected peer
+type hostPeer struct {
+	conn net.Conn
+	name string
+	out  chan []byte
+}
+
+// Listen starts hosting on addr. Peers must present key, which may only be
+// empty on a loopback address, as anyone who can connect drives the timer.
+// Their commands are handed to the program through send.
+func Listen(addr, key string, send func(msg interface{})) (*Host, error) {
+	listener, err := net.Listen("tcp", withDefaultPort(addr))
+	if err != nil {
+		return nil, fmt.Errorf("failed to host team timer: %w", err)
+	}
+	if tcp, ok := listener.Addr().(*net.TCPAddr); key == "" && (!ok || !tcp.IP.IsLoopback()) {
+		listener.Close()
+		return nil, fmt.Errorf("hosting on %s needs a key (-key), or listen on 127.0.0.1 only", addr)
+	}
+	return &Host{
+		listener: listener,
+		key:      key,
+		send:     send,
+		peers:    make(map[*hostPeer]struct{}),
+	}, nil
+}
+
+// Addr is the address peers connect to
+func (h *Host) Addr() string {
+	return h.listener.Addr().String()
+}
+
+// Serve accepts peers until Close is called
+func (h *Host) Serve() {
+	for {
+		conn, err := h.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		go h.handle(conn)
+	}
+}
+
+// Close disconnects all peers and stops listening
+func (h *Host) Close() error {
+	var err error
+	h.closeOnce.Do(func() {
+		err = h.listener.Close()
+		h.mu.Lock()
+		for p := range h.peers {
+			p.conn.Close()
+		}
+		h.mu.Unlock()
+	})
+	return err
+}
+
+// Peers returns the names of the connected peers
+func (h *Host) Peers() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.peerNamesLocked()
+}
+
+// Publish is called with the host's timer state on every tick. Peers are
+// sent the state when the phase or pause state changes, when the time
+// jumps (a restarted phase) and otherwise every HeartbeatInterval.
+func (h *Host) Publish(ps models.PhaseState) {
+	s := StateOf(ps)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	if h.last != nil && !h.changedLocked(s, now) && now.Sub(h.lastSent) < HeartbeatInterval {
+		return
+	}
+	h.last = &s
+	h.lastSent = now
+	h.broadcastLocked(s)
+}
+
+// changedLocked reports whether s differs from the last state sent by more
+// than the passing of time; h.mu must be held
+func (h *Host) changedLocked(s State, now time.Time) bool {
+	last := h.last
+	if s.Mode != last.Mode || s.Session != last.Session || s.TotalSessions != last.TotalSessions ||
+		s.Running != last.Running || s.Paused != last.Paused || s.Cycles != last.Cycles {
+		return true
+	}
+	expected := last.RemainingMillis
+	if last.Running {
+		expected -= now.Sub(h.lastSent).Milliseconds()
+	}
+	drift := s.RemainingMillis - expected
+	return drift > 1000 || drift < -1000
+}
+
+// broadcastLocked sends s to every peer; h.mu must be held
+func (h *Host) broadcastLocked(s State) {
+	s.Peers = h.peerNamesLocked()
+	data := encode(Message{Type: TypeState, State: &s})
+	for p := range h.peers {
+		h.sendLocked(p, data)
+	}
+}
+
+// sendLocked queues data for p, dropping a peer that can't keep up so it
+// reconnects and resynchronizes; h.mu must be held
+func (h *Host) sendLocked(p *hostPeer, data []byte) {
+	select {
+	case p.out <- data:
+	default:
+		p.conn.Close()
+	}
+}
+
+func (h *Host) peerNamesLocked() []string {
+	names := make([]string, 0, len(h.peers))
+	for p := range h.peers {
+		names = append(names, p.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (h *Host) handle(conn net.Conn) {
+	defer conn.Close()
+
+	// The peer has to introduce itself promptly
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	scanner := bufio.NewScanner(conn)
+	if !scanner.Scan() {
+		return
+	}
+	var hello Message
+	if err := json.Unmarshal(scanner.Bytes(), &hello); err != nil || hello.Type != TypeHello {
+		conn.Write(encode(Message{Type: TypeError, Error: "expected hello"}))
+		return
+	}
+	if h.key != "" && subtle.ConstantTimeCompare([]byte(hello.Key), []byte(h.key)) != 1 {
+		conn.Write(encode(Message{Type: TypeError, Error: "wrong team key"}))
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	name := hello.Name
+	if name == "" {
+		name = conn.RemoteAddr().String()
+	}
+	p := &hostPeer{conn: conn, name: name, out: make(chan []byte, 32)}
+
+	h.mu.Lock()
+	h.peers[p] = struct{}{}
+	// Everyone, including the newcomer, gets the current state and the
+	// updated list of peers
+	if h.last != nil {
+		h.lastSent = time.Now()
+		h.broadcastLocked(*h.last)
+	}
+	h.mu.Unlock()
+
+	go h.write(p)
+	defer func() {
+		h.mu.Lock()
+		delete(h.peers, p)
+		close(p.out)
+		if h.last != nil {
+			h.broadcastLocked(*h.last)
+		}
+		h.mu.Unlock()
+	}()
+
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || msg.Type != TypeCommand {
+			continue
+		}
+		var resp control.Response
+		if !peerCommands[msg.Command] {
+			resp.Error = fmt.Sprintf("command %q is not allowed for peers", msg.Command)
+		} else {
+			resp = control.Dispatch(h.send, control.Request{Command: msg.Command})
+		}
+		if !resp.OK {
+			h.mu.Lock()
+			h.sendLocked(p, encode(Message{Type: TypeError, Error: resp.Error}))
+			h.mu.Unlock()
+		}
+	}
+}
+
+// write sends queued messages to p until its queue is closed
+func (h *Host) write(p *hostPeer) {
+	for data := range p.out {
+		p.conn.SetWriteDeadline(time.Now().Add(HeartbeatInterval))
+		if _, err := p.conn.Write(data); err != nil {
+			p.conn.Close()
+			// Drain so the handler never blocks on a full queue
+			for range p.out {
+			}
+			return
+		}
+	}
+}
+
+// encode marshals msg as one protocol line
+func encode(msg Message) []byte {
+	data, _ := json.Marshal(msg)
+	return append(data, '\n')
+}
+
+// withDefaultPort adds DefaultPort to an address without one
+func withDefaultPort(addr string) string {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return net.JoinHostPort(addr, DefaultPort)
+	}
+	return addr
+}
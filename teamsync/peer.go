@@ -0,0 +1,168 @@
+package teamsync
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Reconnect backoff for peers
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 10 * time.Second
+)
+
+// Peer follows a host, reconnecting whenever the connection drops
+type Peer struct {
+	addr string
+	name string
+	key  string
+	send func(msg interface{})
+	conn net.Conn // Current connection, nil while disconnected
+	stop chan struct{}
+	done chan struct{}
+	mu   sync.Mutex
+}
+
+// Join follows the host at addr in the background. States and connection
+// changes are handed to the program through send as StateMsg and ConnMsg.
+func Join(addr, name, key string, send func(msg interface{})) *Peer {
+	p := &Peer{
+		addr: withDefaultPort(addr),
+		name: name,
+		key:  key,
+		send: send,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Addr is the host being followed
+func (p *Peer) Addr() string {
+	return p.addr
+}
+
+// Command asks the host to run a timer command such as "toggle"
+func (p *Peer) Command(command string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return fmt.Errorf("not connected to the team host")
+	}
+	p.conn.SetWriteDeadline(time.Now().Add(HeartbeatInterval))
+	if _, err := p.conn.Write(encode(Message{Type: TypeCommand, Command: command})); err != nil {
+		return fmt.Errorf("failed to reach the team host: %w", err)
+	}
+	return nil
+}
+
+// Close disconnects and stops reconnecting
+func (p *Peer) Close() {
+	close(p.stop)
+	p.mu.Lock()
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.mu.Unlock()
+	<-p.done
+}
+
+func (p *Peer) run() {
+	defer close(p.done)
+
+	delay := minReconnectDelay
+	for {
+		connected, err := p.session()
+		select {
+		case <-p.stop:
+			return
+		default:
+		}
+		p.send(ConnMsg{Connected: false, Err: err})
+
+		if connected {
+			// We had a working connection, retry quickly
+			delay = minReconnectDelay
+		}
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// session runs one connection to the host until it fails, reporting
+// whether it got as far as receiving a state
+func (p *Peer) session() (connected bool, err error) {
+	conn, err := net.DialTimeout("tcp", p.addr, 5*time.Second)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(encode(Message{Type: TypeHello, Name: p.name, Key: p.key})); err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	select {
+	case <-p.stop:
+		// Closed while dialing
+		p.mu.Unlock()
+		return false, nil
+	default:
+	}
+	p.conn = conn
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.conn = nil
+		p.mu.Unlock()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for {
+		// A host that misses several heartbeats is gone
+		conn.SetReadDeadline(time.Now().Add(3 * HeartbeatInterval))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return connected, err
+			}
+			return connected, errors.New("connection closed by host")
+		}
+
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case TypeState:
+			if msg.State == nil {
+				continue
+			}
+			if !connected {
+				connected = true
+				p.send(ConnMsg{Connected: true})
+			}
+			p.send(StateMsg{State: *msg.State})
+		case TypeError:
+			if !connected {
+				// Rejected, e.g. a wrong key
+				return false, errors.New(msg.Error)
+			}
+			p.send(ConnMsg{Connected: true, Err: errors.New(msg.Error)})
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package teamsync shares one Pomodoro timer between instances over TCP.
+// A host is authoritative and broadcasts its timer state; peers follow it
+// and forward their timer commands to the host.
+//
+// The protocol is one JSON message per line. A peer opens with "hello",
+// the host answers with "state" right away, then again on every change
+// and at least every HeartbeatInterval.
+package teamsync
+
+import (
+	"time"
+
+	"zoneout/models"
+)
+
+// DefaultPort is used when an address has no port
+const DefaultPort = "7979"
+
+// HeartbeatInterval is the longest a host stays silent, so peers correct
+// drift and notice a dead connection
+const HeartbeatInterval = 5 * time.Second
+
+// Message types
+const (
+	TypeHello   = "hello"   // peer -> host, first message
+	TypeState   = "state"   // host -> peer
+	TypeCommand = "command" // peer -> host, a control command such as "toggle"
+	TypeError   = "error"   // host -> peer
+)
+
+// Message is one line of the protocol
+type Message struct {
+	Type    string `json:"type"`
+	Name    string `json:"name,omitempty"`    // hello: who is joining
+	Key     string `json:"key,omitempty"`     // hello: must match the host's key, if it has one
+	State   *State `json:"state,omitempty"`   // state
+	Command string `json:"command,omitempty"` // command
+	Error   string `json:"error,omitempty"`   // error
+}
+
+// State is the host's timer as sent on the wire
+type State struct {
+	Mode            string   `json:"mode"` // "focus", "break" or "idle"
+	Session         int      `json:"session"`
+	TotalSessions   int      `json:"total_sessions"`
+	Block           string   `json:"block,omitempty"` // Label of the planned block
+	RemainingMillis int64    `json:"remaining_ms"`
+	TotalMillis     int64    `json:"total_ms"`
+	Running         bool     `json:"running"`
+	Paused          bool     `json:"paused"`
+	Cycles          int      `json:"cycles"`
+	Peers           []string `json:"peers,omitempty"` // Names of everyone following
+}
+
+// StateOf converts a Pomodoro's state for the wire
+func StateOf(s models.PhaseState) State {
+	mode := "idle"
+	switch s.Mode {
+	case models.ModeFocus:
+		mode = "focus"
+	case models.ModeBreak:
+		mode = "break"
+	}
+	return State{
+		Mode:            mode,
+		Session:         s.Session,
+		TotalSessions:   s.TotalSessions,
+		Block:           s.Label,
+		RemainingMillis: s.RemainingTime.Milliseconds(),
+		TotalMillis:     s.TotalTime.Milliseconds(),
+		Running:         s.IsRunning,
+		Paused:          s.IsPaused,
+		Cycles:          s.Cycles,
+	}
+}
+
+// PhaseState converts the wire state back for Pomodoro.Follow
+func (s State) PhaseState() models.PhaseState {
+	mode := models.ModeIdle
+	switch s.Mode {
+	case "focus":
+		mode = models.ModeFocus
+	case "break":
+		mode = models.ModeBreak
+	}
+	return models.PhaseState{
+		Mode:          mode,
+		Session:       s.Session,
+		TotalSessions: s.TotalSessions,
+		Label:         s.Block,
+		RemainingTime: time.Duration(s.RemainingMillis) * time.Millisecond,
+		TotalTime:     time.Duration(s.TotalMillis) * time.Millisecond,
+		IsRunning:     s.Running,
+		IsPaused:      s.Paused,
+		Cycles:        s.Cycles,
+	}
+}
+
+// Messages delivered to the program through the send function
+
+// StateMsg carries a state from the host to a peer's program
+type StateMsg struct {
+	State State
+}
+
+// ConnMsg reports a peer connecting to or losing the host. While
+// connected, Err is an error reported by the host, e.g. a refused command.
+type ConnMsg struct {
+	Connected bool
+	Err       error // Why the connection failed or ended, if known
+}
@@ -0,0 +1,227 @@
+package teamsync
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"zoneout/control"
+	"zoneout/models"
+)
+
+// inbox collects what a host or peer hands to its program
+type inbox chan interface{}
+
+func (in inbox) send(msg interface{}) {
+	in <- msg
+}
+
+// next returns the next message of type T, skipping others
+func next[T any](t *testing.T, in inbox) T {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-in:
+			if m, ok := msg.(T); ok {
+				return m
+			}
+		case <-timeout:
+			var zero T
+			t.Fatalf("timed out waiting for %T", zero)
+			return zero
+		}
+	}
+}
+
+// startHost hosts on a free loopback port; commands from peers are
+// acknowledged and passed on through commands
+func startHost(t *testing.T, key string) (*Host, chan string) {
+	t.Helper()
+	commands := make(chan string, 16)
+	host, err := Listen("127.0.0.1:0", key, func(msg interface{}) {
+		if cmd, ok := msg.(control.CommandMsg); ok {
+			commands <- cmd.Request.Command
+			cmd.Reply(control.Response{OK: true})
+		}
+	})
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	go host.Serve()
+	t.Cleanup(func() { host.Close() })
+	return host, commands
+}
+
+func join(t *testing.T, host *Host, name, key string) (*Peer, inbox) {
+	t.Helper()
+	in := make(inbox, 64)
+	peer := Join(host.Addr(), name, key, in.send)
+	t.Cleanup(peer.Close)
+	return peer, in
+}
+
+func focusState(remaining time.Duration) models.PhaseState {
+	return models.PhaseState{
+		Mode:          models.ModeFocus,
+		Session:       1,
+		TotalSessions: 3,
+		Label:         "deep work",
+		RemainingTime: remaining,
+		TotalTime:     25 * time.Minute,
+		IsRunning:     true,
+	}
+}
+
+func waitForPeers(t *testing.T, host *Host, want ...string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !reflect.DeepEqual(host.Peers(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("peers = %v, want %v", host.Peers(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	host, _ := startHost(t, "hunter2")
+	host.Publish(focusState(20 * time.Minute))
+
+	_, in := join(t, host, "alice", "hunter2")
+	if conn := next[ConnMsg](t, in); !conn.Connected || conn.Err != nil {
+		t.Fatalf("ConnMsg = %+v, want connected", conn)
+	}
+	got := next[StateMsg](t, in).State
+	if got.Mode != "focus" || got.Session != 1 || got.Block != "deep work" || !got.Running {
+		t.Errorf("state = %+v", got)
+	}
+	if got.RemainingMillis != (20 * time.Minute).Milliseconds() {
+		t.Errorf("remaining = %dms, want 20m", got.RemainingMillis)
+	}
+	waitForPeers(t, host, "alice")
+
+	// A second peer is announced to everyone
+	_, in2 := join(t, host, "bob", "hunter2")
+	next[ConnMsg](t, in2)
+	waitForPeers(t, host, "alice", "bob")
+	for {
+		s := next[StateMsg](t, in).State
+		if reflect.DeepEqual(s.Peers, []string{"alice", "bob"}) {
+			break
+		}
+	}
+}
+
+func TestWrongKey(t *testing.T) {
+	host, _ := startHost(t, "hunter2")
+	host.Publish(focusState(20 * time.Minute))
+
+	_, in := join(t, host, "mallory", "guess")
+	conn := next[ConnMsg](t, in)
+	if conn.Connected || conn.Err == nil || !strings.Contains(conn.Err.Error(), "wrong team key") {
+		t.Errorf("ConnMsg = %+v, want rejected for the key", conn)
+	}
+	if peers := host.Peers(); len(peers) != 0 {
+		t.Errorf("peers = %v after a wrong key", peers)
+	}
+}
+
+func TestKeyRequiredOffLoopback(t *testing.T) {
+	if host, err := Listen("0.0.0.0:0", "", func(interface{}) {}); err == nil {
+		host.Close()
+		t.Error("hosting on every interface without a key was allowed")
+	}
+	host, err := Listen("0.0.0.0:0", "hunter2", func(interface{}) {})
+	if err != nil {
+		t.Fatalf("Listen with a key: %v", err)
+	}
+	host.Close()
+	host, err = Listen("127.0.0.1:0", "", func(interface{}) {})
+	if err != nil {
+		t.Fatalf("Listen on loopback without a key: %v", err)
+	}
+	host.Close()
+}
+
+func TestFollowPhases(t *testing.T) {
+	host, _ := startHost(t, "hunter2")
+	host.Publish(focusState(time.Second))
+
+	_, in := join(t, host, "alice", "hunter2")
+	follower := models.NewPomodoro()
+	var events []models.Event
+	follower.AddListener(func(ev models.PhaseEvent) { events = append(events, ev.Event) })
+
+	follower.Follow(next[StateMsg](t, in).State.PhaseState())
+	if follower.CurrentMode != models.ModeFocus || follower.BlockLabel != "deep work" {
+		t.Fatalf("follower in %s %q, want focus on deep work", follower.GetModeString(), follower.BlockLabel)
+	}
+
+	// The host's focus ends and the break starts
+	host.Publish(models.PhaseState{
+		Mode:          models.ModeBreak,
+		Session:       1,
+		TotalSessions: 3,
+		Label:         "break",
+		RemainingTime: 5 * time.Minute,
+		TotalTime:     5 * time.Minute,
+		IsRunning:     true,
+	})
+	var s State
+	for s.Mode != "break" {
+		s = next[StateMsg](t, in).State
+	}
+	follower.Follow(s.PhaseState())
+
+	if follower.CurrentMode != models.ModeBreak || follower.RemainingTime != 5*time.Minute {
+		t.Errorf("follower in %s with %s left, want a 5m break", follower.GetModeString(), follower.RemainingTime)
+	}
+	if follower.CompletedSessions != 1 {
+		t.Errorf("completed = %d, want 1", follower.CompletedSessions)
+	}
+	want := []models.Event{models.EventFocusStart, models.EventFocusEnd, models.EventBreakStart}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+func TestPeerCommands(t *testing.T) {
+	host, commands := startHost(t, "hunter2")
+	host.Publish(focusState(20 * time.Minute))
+
+	peer, in := join(t, host, "alice", "hunter2")
+	next[StateMsg](t, in)
+
+	if err := peer.Command(control.CommandToggle); err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+	select {
+	case cmd := <-commands:
+		if cmd != control.CommandToggle {
+			t.Errorf("host ran %q, want toggle", cmd)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the host never got the command")
+	}
+
+	// Peers may not stop the host
+	if err := peer.Command(control.CommandShutdown); err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+	for {
+		conn := next[ConnMsg](t, in)
+		if conn.Err != nil {
+			if !conn.Connected || !strings.Contains(conn.Err.Error(), "not allowed") {
+				t.Errorf("ConnMsg = %+v, want the command refused", conn)
+			}
+			break
+		}
+	}
+	select {
+	case cmd := <-commands:
+		t.Errorf("host ran %q for a peer", cmd)
+	default:
+	}
+}
@@ -0,0 +1,160 @@
+// Package timew tracks focus sessions in Timewarrior: `timew start` when a
+// focus phase starts or resumes, `timew stop` when it ends, pauses or the
+// cycle is reset.
+package timew
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"zoneout/models"
+	"zoneout/tasks"
+)
+
+// commandTimeout bounds one run of the timew binary
+const commandTimeout = 10 * time.Second
+
+// Tracker runs timew on timer events. Commands run in order on a
+// background goroutine, so the timer never waits for Timewarrior.
+type Tracker struct {
+	binary       string
+	activeTask   func() (tasks.Task, bool)
+	queue        chan []string
+	done         chan struct{}
+	tracking     bool // A start was sent without a stop
+	pendingError error
+	mu           sync.Mutex
+}
+
+// New uses the timew binary on PATH. activeTask provides the task whose
+// description, project and tags are used as Timewarrior tags; it may be
+// nil.
+func New(activeTask func() (tasks.Task, bool)) (*Tracker, error) {
+	binary, err := exec.LookPath("timew")
+	if err != nil {
+		return nil, fmt.Errorf("timewarrior: timew not found on PATH")
+	}
+	t := &Tracker{
+		binary:     binary,
+		activeTask: activeTask,
+		queue:      make(chan []string, 16),
+		done:       make(chan struct{}),
+	}
+	go t.run()
+	return t, nil
+}
+
+// Fire starts or stops tracking for ev. It has the models.Listener
+// signature so it can be registered on a Pomodoro.
+func (t *Tracker) Fire(ev models.PhaseEvent) {
+	switch ev.Event {
+	case models.EventFocusStart:
+		t.start(ev.Task)
+	case models.EventResume:
+		if ev.Mode == "FOCUS" {
+			t.start(ev.Task)
+		}
+	case models.EventFocusEnd, models.EventPause, models.EventReset:
+		t.stop()
+	}
+}
+
+// Close stops tracking and waits for the queued commands
+func (t *Tracker) Close() {
+	t.stop()
+	close(t.queue)
+	<-t.done
+}
+
+// TakeError returns a timew failure that has not been reported yet and
+// clears it
+func (t *Tracker) TakeError() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.pendingError
+	t.pendingError = nil
+	return err
+}
+
+// tags returns the Timewarrior tags for a focus session on task: its
+// description, project and tags. title is used without task details, and
+// "zoneout" when there is no task at all.
+func tags(task tasks.Task, ok bool, title string) []string {
+	if !ok {
+		if title != "" {
+			return []string{title}
+		}
+		return []string{"zoneout"}
+	}
+	tags := []string{task.Title}
+	tags = append(tags, task.Projects...)
+	return append(tags, task.Tags...)
+}
+
+func (t *Tracker) start(title string) {
+	var task tasks.Task
+	ok := false
+	if t.activeTask != nil {
+		task, ok = t.activeTask()
+	}
+
+	t.mu.Lock()
+	t.tracking = true
+	t.mu.Unlock()
+	t.enqueue(append([]string{"start"}, tags(task, ok, title)...))
+}
+
+func (t *Tracker) stop() {
+	t.mu.Lock()
+	tracking := t.tracking
+	t.tracking = false
+	t.mu.Unlock()
+	if tracking {
+		t.enqueue([]string{"stop"})
+	}
+}
+
+func (t *Tracker) enqueue(args []string) {
+	select {
+	case t.queue <- args:
+	default:
+		t.setError(fmt.Errorf("timewarrior: too many commands queued, dropped timew %s", args[0]))
+	}
+}
+
+func (t *Tracker) run() {
+	defer close(t.done)
+	for args := range t.queue {
+		if err := t.timew(args); err != nil {
+			t.setError(err)
+		}
+	}
+}
+
+func (t *Tracker) timew(args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, t.binary, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("timew %s: %s", args[0], msg)
+		}
+		return fmt.Errorf("timew %s: %w", args[0], err)
+	}
+	return nil
+}
+
+func (t *Tracker) setError(err error) {
+	t.mu.Lock()
+	t.pendingError = err
+	t.mu.Unlock()
+}
@@ -0,0 +1,110 @@
+package timew
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"zoneout/models"
+	"zoneout/tasks"
+)
+
+// fakeTimew puts a timew script on PATH that logs its arguments, one line
+// per run
+func fakeTimew(t *testing.T) (log string) {
+	t.Helper()
+	dir := t.TempDir()
+	log = filepath.Join(dir, "log")
+	script := fmt.Sprintf(`#!/bin/sh
+for arg in "$@"; do printf '%%s|' "$arg"; done >> %[1]q
+printf '\n' >> %[1]q
+`, log)
+	if err := os.WriteFile(filepath.Join(dir, "timew"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	return log
+}
+
+func runs(t *testing.T, log string) []string {
+	t.Helper()
+	data, err := os.ReadFile(log)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		lines = append(lines, strings.TrimSuffix(line, "|"))
+	}
+	return lines
+}
+
+func event(ev models.Event, mode string) models.PhaseEvent {
+	return models.PhaseEvent{Event: ev, Mode: mode, Task: "Write report"}
+}
+
+func TestTrackFocus(t *testing.T) {
+	log := fakeTimew(t)
+	task := tasks.Task{Title: "Write report", Projects: []string{"work"}, Tags: []string{"writing"}}
+	tracker, err := New(func() (tasks.Task, bool) { return task, true })
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for _, ev := range []models.PhaseEvent{
+		event(models.EventFocusStart, "FOCUS"),
+		event(models.EventPause, "FOCUS"),
+		event(models.EventPause, "FOCUS"), // Already stopped
+		event(models.EventResume, "FOCUS"),
+		event(models.EventFocusEnd, "FOCUS"),
+		event(models.EventBreakStart, "BREAK"),
+		event(models.EventPause, "BREAK"),
+		event(models.EventResume, "BREAK"), // Breaks aren't tracked
+		event(models.EventReset, "BREAK"),
+		event(models.EventFocusStart, "FOCUS"),
+		event(models.EventReset, "FOCUS"),
+	} {
+		tracker.Fire(ev)
+	}
+	tracker.Close()
+
+	start := "start|Write report|work|writing"
+	want := []string{start, "stop", start, "stop", start, "stop"}
+	if r := runs(t, log); !reflect.DeepEqual(r, want) {
+		t.Errorf("runs = %q\nwant %q", r, want)
+	}
+	if err := tracker.TakeError(); err != nil {
+		t.Errorf("TakeError = %v", err)
+	}
+}
+
+func TestTrackWithoutTask(t *testing.T) {
+	log := fakeTimew(t)
+	tracker, err := New(func() (tasks.Task, bool) { return tasks.Task{}, false })
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	tracker.Fire(event(models.EventFocusStart, "FOCUS"))
+	ev := event(models.EventFocusStart, "FOCUS")
+	ev.Task = ""
+	tracker.Fire(ev)
+	tracker.Close() // Stops tracking
+
+	want := []string{"start|Write report", "start|zoneout", "stop"}
+	if r := runs(t, log); !reflect.DeepEqual(r, want) {
+		t.Errorf("runs = %q\nwant %q", r, want)
+	}
+}
+
+func TestTimewMissing(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if _, err := New(nil); err == nil {
+		t.Error("New succeeded without timew on PATH")
+	}
+}
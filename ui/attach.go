@@ -0,0 +1,285 @@
+package ui
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"zoneout/audio"
+	"zoneout/control"
+	"zoneout/models"
+	"zoneout/stats"
+	"zoneout/tasks"
+)
+
+// Attached dashboards: the same Model showing a daemon's timer. Keys act
+// through the control socket and the dashboard is drawn from the status
+// the daemon replies with, so any number of them can attach and detach.
+
+// daemonPollInterval is how often an attached dashboard refreshes the
+// daemon's status
+const daemonPollInterval = 250 * time.Millisecond
+
+// attachedOnlyTimer answers keys that work on the timer's clock and audio
+// directly, which only the daemon has
+const attachedOnlyTimer = "Only in the dashboard that runs the timer"
+
+// daemonLink connects an attached dashboard to the daemon
+type daemonLink struct {
+	socketPath string
+	status     control.Status // Last reported
+	connected  bool           // Once a status came in
+	err        error          // Of the last request, nil once the daemon answers again
+	tasks      *daemonTasks
+}
+
+// daemonStatusMsg carries a polled status
+type daemonStatusMsg struct {
+	resp control.Response
+	err  error
+}
+
+// NewAttachedModel creates a dashboard for the daemon listening on
+// socketPath. The daemon runs the timer, audio and tasks; appStats, on the
+// same config directory, is only read for the session history.
+func NewAttachedModel(socketPath string, appStats *stats.Stats) *Model {
+	source := &daemonTasks{socketPath: socketPath}
+	return &Model{
+		pomodoro:    models.NewPomodoro(), // Mirrors the daemon's
+		appStats:    appStats,
+		sleepPreset: -1,
+		taskSource:  source,
+		daemon:      &daemonLink{socketPath: socketPath, tasks: source},
+	}
+}
+
+// pollDaemon asks the daemon for its status after delay
+func (m *Model) pollDaemon(delay time.Duration) tea.Cmd {
+	socketPath := m.daemon.socketPath
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		resp, err := control.Send(socketPath, control.Request{Command: control.CommandStatus})
+		return daemonStatusMsg{resp: resp, err: err}
+	})
+}
+
+// daemonReplied takes the status from a reply of the daemon
+func (m *Model) daemonReplied(resp control.Response, err error) {
+	m.daemon.err = err
+	if err != nil || resp.Status == nil {
+		return
+	}
+	m.daemon.status = *resp.Status
+	m.daemon.connected = true
+	m.followDaemon(*resp.Status)
+}
+
+// followDaemon mirrors the daemon's timer, for the keys and panels that
+// look at the Pomodoro
+func (m *Model) followDaemon(s control.Status) {
+	p := m.pomodoro
+	switch s.Mode {
+	case "focus":
+		p.CurrentMode = models.ModeFocus
+	case "break":
+		p.CurrentMode = models.ModeBreak
+	default:
+		p.CurrentMode = models.ModeIdle
+	}
+	if s.Plan != p.Plan.String() {
+		if plan, err := models.ParsePlan(s.Plan); err == nil {
+			p.Plan = plan
+		}
+	}
+	p.Block = s.BlockIndex
+	p.BlockLabel = s.Block
+	p.CurrentSession = s.Session
+	p.TotalSessions = s.TotalSessions
+	p.RemainingTime = time.Duration(s.RemainingSeconds) * time.Second
+	p.TotalTime = time.Duration(s.TotalSeconds) * time.Second
+	p.IsRunning = s.Running
+	p.IsPaused = s.Paused
+	p.CompletedSessions = s.CompletedSessions
+	p.Task = s.Task
+	m.sessionTags = s.SessionTags
+}
+
+// daemonRequest runs req on the daemon, reporting the error it replies with
+func (m *Model) daemonRequest(req control.Request) (control.Response, error) {
+	resp, err := control.Send(m.daemon.socketPath, req)
+	m.daemonReplied(resp, err)
+	if err == nil && !resp.OK {
+		err = errors.New(resp.Error)
+	}
+	return resp, err
+}
+
+// sendToDaemon runs req on the daemon
+func (m *Model) sendToDaemon(req control.Request) error {
+	_, err := m.daemonRequest(req)
+	return err
+}
+
+// forwardToDaemon runs req on the daemon when attached to one, reporting
+// whether it did. Failures are shown as toasts.
+func (m *Model) forwardToDaemon(req control.Request) bool {
+	if m.daemon == nil {
+		return false
+	}
+	if err := m.sendToDaemon(req); err != nil {
+		m.showToast(err.Error())
+	}
+	return true
+}
+
+// loadDaemonSounds lists the daemon's MP3s and tones in the audio menu
+func (m *Model) loadDaemonSounds() {
+	resp, err := m.daemonRequest(control.Request{Command: control.CommandListSounds})
+	if err != nil {
+		m.showToast(err.Error())
+		return
+	}
+	m.availableMP3s = resp.Sounds[:max(len(resp.Sounds)-len(resp.Tones), 0)]
+	m.tonePresets = nil
+	for _, name := range resp.Tones {
+		m.tonePresets = append(m.tonePresets, audio.ToneConfig{Name: name})
+	}
+	if m.selectedMP3 >= m.audioMenuLength() {
+		m.selectedMP3 = 0
+	}
+}
+
+// loadDaemonTasks fetches the daemon's tasks for the task panel
+func (m *Model) loadDaemonTasks() error {
+	if err := m.daemon.tasks.load(); err != nil {
+		return err
+	}
+	m.refreshTaskTotals()
+	return nil
+}
+
+// daemonTasks is the daemon's task list as a task source. It lists the
+// tasks as last loaded and loads them again after every change.
+type daemonTasks struct {
+	socketPath string
+	list       []control.Task
+	mu         sync.Mutex // Add runs in the background
+}
+
+// send runs req on the daemon, reporting the error it replies with
+func (d *daemonTasks) send(req control.Request) (control.Response, error) {
+	resp, err := control.Send(d.socketPath, req)
+	if err == nil && !resp.OK {
+		err = errors.New(resp.Error)
+	}
+	return resp, err
+}
+
+func (d *daemonTasks) load() error {
+	resp, err := d.send(control.Request{Command: control.CommandListTasks})
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.list = resp.Tasks
+	return nil
+}
+
+// change runs a task command and loads the changed list
+func (d *daemonTasks) change(req control.Request) error {
+	if _, err := d.send(req); err != nil {
+		return err
+	}
+	return d.load()
+}
+
+// fromControl turns a task as listed by the daemon back into a task
+func fromControl(t control.Task) tasks.Task {
+	return tasks.Task{
+		ID:        t.ID,
+		Title:     t.Title,
+		Priority:  t.Priority,
+		Projects:  t.Projects,
+		Contexts:  t.Contexts,
+		Tags:      t.Tags,
+		Done:      t.Done,
+		Estimate:  t.Estimate,
+		Pomodoros: t.Pomodoros,
+	}
+}
+
+func (d *daemonTasks) List() []tasks.Task {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := make([]tasks.Task, len(d.list))
+	for i, t := range d.list {
+		list[i] = fromControl(t)
+	}
+	return list
+}
+
+func (d *daemonTasks) Active() (tasks.Task, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, t := range d.list {
+		if t.Active {
+			return fromControl(t), true
+		}
+	}
+	return tasks.Task{}, false
+}
+
+// Format gives the task as the daemon's source takes it
+func (d *daemonTasks) Format(t tasks.Task) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, c := range d.list {
+		if c.ID == t.ID {
+			return c.Input
+		}
+	}
+	return tasks.FormatTitle(t)
+}
+
+func (d *daemonTasks) Add(input string) (tasks.Task, error) {
+	before := make(map[string]bool)
+	for _, t := range d.List() {
+		before[t.ID] = true
+	}
+	if err := d.change(control.Request{Command: control.CommandAddTask, Task: input}); err != nil {
+		return tasks.Task{}, err
+	}
+	// The new one is the task that wasn't there
+	for _, t := range d.List() {
+		if !before[t.ID] {
+			return t, nil
+		}
+	}
+	return tasks.Task{}, nil
+}
+
+func (d *daemonTasks) Edit(id, input string) error {
+	return d.change(control.Request{Command: control.CommandEditTask, Task: id, Text: input})
+}
+
+func (d *daemonTasks) SetEstimate(id string, estimate int) error {
+	return d.change(control.Request{Command: control.CommandEstimate, Task: id, Estimate: estimate})
+}
+
+func (d *daemonTasks) SetDone(id string, done bool) error {
+	command := control.CommandDoneTask
+	if !done {
+		command = control.CommandReopenTask
+	}
+	return d.change(control.Request{Command: command, Task: id})
+}
+
+// AddPomodoro is never needed, the daemon counts the sessions it records
+func (d *daemonTasks) AddPomodoro(id string) error {
+	return errors.New("pomodoros are counted by the daemon")
+}
+
+func (d *daemonTasks) SetActive(id string) error {
+	return d.change(control.Request{Command: control.CommandSelectTask, Task: id})
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"zoneout/calendar"
+	"zoneout/control"
+	"zoneout/models"
+)
+
+// calendarSlack is how far past a meeting's start a focus phase may run
+// before it is shortened, so small timer drift doesn't shorten it again
+const calendarSlack = 5 * time.Second
+
+// calendarMinFocus is the shortest focus phase worth having before a
+// meeting; a shorter one is skipped rather than counted as a session
+const calendarMinFocus = 5 * time.Minute
+
+// SetCalendar makes focus phases end before the meetings in cal
+func (m *Model) SetCalendar(cal *calendar.Calendar) {
+	m.calendar = cal
+}
+
+// checkCalendar fits a running focus phase around the meetings: it is
+// paused when it starts during one, shortened when it would run into the
+// next and skipped, uncounted, when too little of it would be left
+func (m *Model) checkCalendar() {
+	// A team host plans the phases for its followers
+	if m.calendar == nil || m.teamPeer != nil || m.pomodoro.CurrentMode != models.ModeFocus || !m.pomodoro.IsRunning {
+		return
+	}
+	now := time.Now()
+	starting := !m.calendarSeen.Equal(m.pomodoro.PhaseStarted)
+	m.calendarSeen = m.pomodoro.PhaseStarted
+
+	// Resuming focuses anyway
+	if ev, ok := m.calendar.Current(now); ok && starting {
+		m.pause()
+		m.showToast(fmt.Sprintf("%s is on until %s, focus paused (space to focus anyway)", ev.Summary, ev.End.Format("15:04")))
+		return
+	}
+
+	ev, ok := m.calendar.Next(now)
+	if !ok || !now.Add(m.pomodoro.RemainingTime).After(ev.Start.Add(calendarSlack)) {
+		return
+	}
+	left := ev.Start.Sub(now)
+	if m.pomodoro.TotalTime-m.pomodoro.RemainingTime+left < calendarMinFocus {
+		m.skip()
+		m.showToast(fmt.Sprintf("Only %s before %s, focus skipped", formatLeft(left), ev.Summary))
+		return
+	}
+	if m.pomodoro.EndBy(ev.Start) {
+		m.showToast(fmt.Sprintf("%s at %s, focus shortened to %s", ev.Summary, ev.Start.Format("15:04"), formatLeft(m.pomodoro.TotalTime)))
+	}
+}
+
+// formatLeft shows a duration rounded up to the minute, or in seconds
+// under one
+func formatLeft(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(math.Ceil(d.Seconds())))
+	}
+	return formatMinutes(int(math.Ceil(d.Minutes())))
+}
+
+// meeting is the meeting in progress or the next one, nil when there is
+// none in the next 12 hours
+func (m *Model) meeting(now time.Time) *control.Meeting {
+	if m.calendar == nil {
+		return nil
+	}
+	ev, ok := m.calendar.Current(now)
+	if !ok {
+		ev, ok = m.calendar.Next(now)
+	}
+	if !ok || ev.Start.Sub(now) > 12*time.Hour {
+		return nil
+	}
+	return &control.Meeting{Summary: ev.Summary, Start: ev.Start, End: ev.End}
+}
+
+// calendarLine shows the status' meeting on the dashboard, empty when
+// there is none
+func calendarLine(s control.Status) string {
+	ev := s.Meeting
+	if ev == nil {
+		return ""
+	}
+	now := time.Now()
+	if !ev.Start.After(now) {
+		return fmt.Sprintf("📅 %s until %s", ev.Summary, ev.End.Format("15:04"))
+	}
+	minutes := int(math.Ceil(ev.Start.Sub(now).Minutes()))
+	return fmt.Sprintf("📅 Next meeting in %s: %s (%s)", formatMinutes(minutes), ev.Summary, ev.Start.Format("15:04"))
+}
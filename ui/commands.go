@@ -0,0 +1,482 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"zoneout/control"
+	"zoneout/models"
+	"zoneout/stats"
+	"zoneout/tasks"
+)
+
+// Timer actions shared by the keyboard and the control socket
+
+// forwardToHost sends a timer command to the team host when following
+// one, reporting whether it did
+func (m *Model) forwardToHost(command string) bool {
+	if m.teamPeer == nil {
+		return false
+	}
+	if err := m.teamPeer.Command(command); err != nil {
+		m.showToast(err.Error())
+	}
+	return true
+}
+
+// start begins a new cycle, or resumes a paused one
+func (m *Model) start() {
+	if m.forwardToDaemon(control.Request{Command: control.CommandStart}) {
+		return
+	}
+	if m.forwardToHost(control.CommandStart) {
+		return
+	}
+	if m.pomodoro.IsPaused {
+		m.resume()
+		return
+	}
+	if m.pomodoro.CurrentMode == models.ModeIdle {
+		m.cancelWindDown()
+		m.pomodoro.Start()
+		m.lastTickTime = time.Now()
+		m.checkCalendar()
+	}
+}
+
+// toggle starts, pauses or resumes depending on the current state, like SPACE
+func (m *Model) toggle() {
+	if m.forwardToDaemon(control.Request{Command: control.CommandToggle}) {
+		return
+	}
+	if m.forwardToHost(control.CommandToggle) {
+		return
+	}
+	if m.pomodoro.CurrentMode == models.ModeIdle {
+		m.start()
+	} else if m.pomodoro.IsRunning && !m.pomodoro.IsPaused {
+		m.pause()
+	} else if m.pomodoro.IsPaused {
+		m.resume()
+	}
+}
+
+func (m *Model) pause() {
+	if m.forwardToDaemon(control.Request{Command: control.CommandPause}) {
+		return
+	}
+	if m.forwardToHost(control.CommandPause) {
+		return
+	}
+	if m.pomodoro.IsRunning && !m.pomodoro.IsPaused {
+		m.pomodoro.Pause()
+		m.audioPlayer.Pause()
+		m.lastTickTime = time.Now() // Reset time to avoid jump on resume
+	}
+}
+
+func (m *Model) resume() {
+	if m.forwardToDaemon(control.Request{Command: control.CommandResume}) {
+		return
+	}
+	if m.forwardToHost(control.CommandResume) {
+		return
+	}
+	if m.pomodoro.IsPaused {
+		m.pomodoro.Resume()
+		m.audioPlayer.Resume()
+		m.lastTickTime = time.Now() // Reset time to avoid jump on resume
+	}
+}
+
+// skip moves to the next phase, reporting whether there was one to skip
+func (m *Model) skip() bool {
+	if m.forwardToDaemon(control.Request{Command: control.CommandSkip}) {
+		return true
+	}
+	if m.forwardToHost(control.CommandSkip) {
+		return true
+	}
+	if !m.pomodoro.IsRunning && !m.pomodoro.IsPaused {
+		return false
+	}
+	m.pomodoro.Skip()
+	m.recordedFocus = m.pomodoro.LastFocus.End // Skipped sessions don't count
+	// Update audio mode after skipping
+	m.updateAudioMode()
+	return true
+}
+
+// reset ends the cycle, for the whole team when following a host
+func (m *Model) reset() {
+	if m.forwardToDaemon(control.Request{Command: control.CommandReset}) {
+		return
+	}
+	if m.forwardToHost(control.CommandReset) {
+		return
+	}
+	m.resetCycle()
+}
+
+// resetCycle ends the cycle on this instance only
+func (m *Model) resetCycle() {
+	m.cancelWindDown()
+	m.pomodoro.Reset()
+	m.audioPlayer.Stop()
+}
+
+// setVolume applies and persists a volume level, clamped to 0.0-1.0
+func (m *Model) setVolume(volume float64) {
+	if m.forwardToDaemon(control.Request{Command: control.CommandSetVolume, Volume: volume}) {
+		return
+	}
+	m.audioPlayer.SetVolume(volume)
+	if m.appConfig != nil {
+		m.appConfig.SetVolume(m.audioPlayer.GetVolume())
+	}
+	// Volume will be applied on next audio start
+}
+
+// selectSound plays the MP3 or selects the tone named name, or else the
+// first whose name contains it; "off" or "none" turns the tone off
+func (m *Model) selectSound(name string) error {
+	if m.daemon != nil {
+		return m.sendToDaemon(control.Request{Command: control.CommandSelectSound, Sound: name})
+	}
+	query := strings.ToLower(strings.TrimSpace(name))
+	if query == "" {
+		return fmt.Errorf("no sound given")
+	}
+	if query == "off" || query == "none" {
+		return m.audioPlayer.SelectTone(nil)
+	}
+
+	for _, exact := range []bool{true, false} {
+		matches := func(name string) bool {
+			name = strings.ToLower(name)
+			return name == query || (!exact && strings.Contains(name, query))
+		}
+		for _, mp3 := range m.availableMP3s {
+			if matches(filepath.Base(mp3)) {
+				m.audioRetryAt = time.Time{}
+				return m.audioPlayer.PlayMP3(mp3)
+			}
+		}
+		for i, tone := range m.tonePresets {
+			if matches(tone.Name) {
+				return m.audioPlayer.SelectTone(&m.tonePresets[i])
+			}
+		}
+	}
+	return fmt.Errorf("no sound matching %q", name)
+}
+
+// taskList lists the tasks for control clients
+func (m *Model) taskList() ([]control.Task, error) {
+	if m.taskSource == nil {
+		return nil, fmt.Errorf("tasks are not enabled")
+	}
+	active, _ := m.taskSource.Active()
+	var list []control.Task
+	for _, t := range m.taskSource.List() {
+		list = append(list, control.Task{
+			ID:        t.ID,
+			Title:     t.Title,
+			Done:      t.Done,
+			Active:    t.ID == active.ID,
+			Pomodoros: t.Pomodoros,
+			Estimate:  t.Estimate,
+			Priority:  t.Priority,
+			Projects:  t.Projects,
+			Contexts:  t.Contexts,
+			Tags:      t.Tags,
+			Input:     tasks.Format(m.taskSource, t),
+		})
+	}
+	return list, nil
+}
+
+// findTask returns the task with ID query, or else the first open one
+// whose title contains it
+func (m *Model) findTask(query string) (tasks.Task, error) {
+	if m.taskSource == nil {
+		return tasks.Task{}, fmt.Errorf("tasks are not enabled")
+	}
+	list := m.taskSource.List()
+	for _, t := range list {
+		if t.ID == query {
+			return t, nil
+		}
+	}
+	lower := strings.ToLower(strings.TrimSpace(query))
+	for _, t := range list {
+		if !t.Done && strings.Contains(strings.ToLower(t.Title), lower) {
+			return t, nil
+		}
+	}
+	return tasks.Task{}, fmt.Errorf("no task matching %q", query)
+}
+
+// selectTask makes the task matching query the active one, "" for none
+func (m *Model) selectTask(query string) error {
+	if m.taskSource == nil {
+		return fmt.Errorf("tasks are not enabled")
+	}
+	id := ""
+	if query != "" {
+		t, err := m.findTask(query)
+		if err != nil {
+			return err
+		}
+		id = t.ID
+	}
+	err := m.taskSource.SetActive(id)
+	m.syncActiveTask()
+	return err
+}
+
+// addTask adds a task in the background and replies to msg once it is
+// added, "~N" at the end of the title estimates it
+func (m *Model) addTask(msg control.CommandMsg) (tea.Cmd, error) {
+	if m.taskSource == nil {
+		return nil, fmt.Errorf("tasks are not enabled")
+	}
+	if strings.TrimSpace(msg.Request.Task) == "" {
+		return nil, fmt.Errorf("no task given")
+	}
+	return m.addTaskCmd(msg.Request.Task, &msg), nil
+}
+
+// completeTask marks the task matching query as done, or open again
+func (m *Model) completeTask(query string, done bool) error {
+	t, err := m.findTask(query)
+	if err != nil {
+		return err
+	}
+	err = m.taskSource.SetDone(t.ID, done)
+	m.syncActiveTask()
+	return err
+}
+
+// editTask replaces the task matching query with input, written as for
+// adding a task
+func (m *Model) editTask(query, input string) error {
+	t, err := m.findTask(query)
+	if err != nil {
+		return err
+	}
+	err = m.taskSource.Edit(t.ID, input)
+	m.syncActiveTask()
+	return err
+}
+
+// estimateTask sets how many pomodoros the task matching query should take
+func (m *Model) estimateTask(query string, estimate int) error {
+	t, err := m.findTask(query)
+	if err != nil {
+		return err
+	}
+	return m.taskSource.SetEstimate(t.ID, estimate)
+}
+
+// interrupt logs an interruption of the focus session with its note, without
+// asking for one
+func (m *Model) interrupt(kind, note string) error {
+	if m.daemon != nil {
+		return m.sendToDaemon(control.Request{Command: control.CommandInterrupt, Kind: kind, Note: note})
+	}
+	if kind != stats.InterruptionInternal && kind != stats.InterruptionExternal {
+		return fmt.Errorf("unknown interruption %q, use %s or %s", kind, stats.InterruptionInternal, stats.InterruptionExternal)
+	}
+	if m.pomodoro.CurrentMode != models.ModeFocus {
+		return fmt.Errorf("not focusing")
+	}
+	m.interruptions = append(m.focusInterruptions(m.pomodoro.PhaseStarted), stats.Interruption{
+		At:   time.Now(),
+		Kind: kind,
+		Note: note,
+	})
+	return nil
+}
+
+// setTags replaces the tags picked for the focus session
+func (m *Model) setTags(tags []string) {
+	m.sessionTags = nil
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(tag), "-")
+		if tag != "" && !containsTag(m.sessionTags, tag) {
+			m.toggleTag(tag)
+		}
+	}
+}
+
+// handleCommand runs a request from the control socket and replies with the
+// resulting status
+func (m *Model) handleCommand(msg control.CommandMsg) tea.Cmd {
+	var err error
+	var cmd tea.Cmd
+	var sounds, tones []string
+	var summary *control.Stats
+	var taskList []control.Task
+
+	switch msg.Request.Command {
+	case control.CommandStart:
+		m.start()
+	case control.CommandPause:
+		m.pause()
+	case control.CommandResume:
+		m.resume()
+	case control.CommandToggle:
+		m.toggle()
+	case control.CommandSkip:
+		if !m.skip() {
+			err = fmt.Errorf("timer is not running")
+		}
+	case control.CommandReset:
+		m.reset()
+	case control.CommandSetVolume:
+		m.setVolume(msg.Request.Volume)
+	case control.CommandSelectSound:
+		err = m.selectSound(msg.Request.Sound)
+	case control.CommandStatus:
+		// Nothing to do, every reply carries the status
+	case control.CommandListSounds:
+		sounds = m.soundNames()
+		for _, tone := range m.tonePresets {
+			tones = append(tones, tone.Name)
+		}
+	case control.CommandStats:
+		summary = m.stats()
+	case control.CommandListTasks:
+		taskList, err = m.taskList()
+	case control.CommandAddTask:
+		if cmd, err = m.addTask(msg); cmd != nil {
+			return cmd // Replies once the task is added
+		}
+	case control.CommandSelectTask:
+		err = m.selectTask(msg.Request.Task)
+	case control.CommandDoneTask, control.CommandReopenTask:
+		err = m.completeTask(msg.Request.Task, msg.Request.Command == control.CommandDoneTask)
+	case control.CommandEditTask:
+		err = m.editTask(msg.Request.Task, msg.Request.Text)
+	case control.CommandEstimate:
+		err = m.estimateTask(msg.Request.Task, msg.Request.Estimate)
+	case control.CommandInterrupt:
+		err = m.interrupt(msg.Request.Kind, msg.Request.Note)
+	case control.CommandSetTags:
+		m.setTags(msg.Request.Tags)
+	case control.CommandSetPlan:
+		err = m.setPlan(msg.Request.Plan)
+	case control.CommandShutdown:
+		m.resetCycle()
+		cmd = tea.Quit
+	default:
+		err = fmt.Errorf("unknown command %q", msg.Request.Command)
+	}
+
+	status := m.status()
+	resp := control.Response{OK: err == nil, Status: &status, Sounds: sounds, Tones: tones, Stats: summary, Tasks: taskList}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	msg.Reply(resp)
+	return cmd
+}
+
+// soundNames lists the MP3 file names followed by the tone preset names, in
+// audio menu order
+func (m *Model) soundNames() []string {
+	var names []string
+	for _, mp3 := range m.availableMP3s {
+		names = append(names, filepath.Base(mp3))
+	}
+	for _, tone := range m.tonePresets {
+		names = append(names, tone.Name)
+	}
+	return names
+}
+
+// status snapshots the timer and audio state for control clients and the
+// dashboard. Attached to a daemon, it is the daemon's last reported status.
+func (m *Model) status() control.Status {
+	if m.daemon != nil {
+		return m.daemon.status
+	}
+
+	remaining := m.pomodoro.RemainingTime
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	s := control.Status{
+		Mode:              strings.ToLower(m.pomodoro.GetModeString()),
+		Running:           m.pomodoro.IsRunning,
+		Paused:            m.pomodoro.IsPaused,
+		Remaining:         m.pomodoro.FormatTime(),
+		RemainingSeconds:  int(remaining.Seconds()),
+		TotalSeconds:      int(m.pomodoro.TotalTime.Seconds()),
+		Session:           m.pomodoro.CurrentSession,
+		TotalSessions:     m.pomodoro.TotalSessions,
+		Block:             m.pomodoro.BlockLabel,
+		BlockIndex:        m.pomodoro.Block,
+		CompletedSessions: m.pomodoro.CompletedSessions,
+		Pauses:            m.pomodoro.Pauses,
+		Skips:             m.pomodoro.Skips,
+		Task:              m.pomodoro.Task,
+		Tags:              m.focusTags(),
+		SessionTags:       m.sessionTags,
+		Plan:              m.pomodoro.Plan.String(),
+		Volume:            m.audioPlayer.GetVolume(),
+		TodaySessions:     m.appStats.GetTodaySessions(),
+		Badge:             m.appStats.GetBadge(),
+		BadgeDescription:  m.appStats.GetBadgeDescription(),
+		Player:            m.audioDiag.Player,
+		EmbeddedSounds:    m.audioDiag.EmbeddedLoaded,
+	}
+	if m.taskSource != nil {
+		if t, ok := m.taskSource.Active(); ok {
+			s.TaskPomodoros = t.Pomodoros
+			s.TaskEstimate = t.Estimate
+		}
+	}
+	if m.pomodoro.CurrentMode == models.ModeFocus {
+		rec := stats.SessionRecord{Interruptions: m.focusInterruptions(m.pomodoro.PhaseStarted)}
+		s.InternalInterruptions, s.ExternalInterruptions = rec.InterruptionCounts()
+	}
+	s.Meeting = m.meeting(time.Now())
+	if m.isWindingDown() {
+		s.WindDown = m.windDownLabel
+		s.WindDownSeconds = int(time.Until(m.windDownUntil).Round(time.Second).Seconds())
+	}
+	s.Team = m.team()
+	if m.audioDiag.LastError != nil {
+		s.AudioError = m.audioDiag.LastError.Error()
+	}
+	if motd, ok := m.motdManager.(interface{ GetMessage() string }); ok && motd != nil {
+		s.Message = motd.GetMessage()
+	}
+	if sound := m.audioPlayer.GetCurrentMP3(); sound != "" {
+		s.Sound = filepath.Base(sound)
+	} else if len(m.availableMP3s) > 0 {
+		s.Sound = filepath.Base(m.availableMP3s[0])
+	}
+	if tone := m.audioPlayer.SelectedTone(); tone != nil {
+		s.Tone = tone.Name
+	}
+	return s
+}
+
+// stats summarizes the session statistics for the control protocol
+func (m *Model) stats() *control.Stats {
+	return &control.Stats{
+		TotalSessions:     m.appStats.GetTotalSessions(),
+		TodaySessions:     m.appStats.GetTodaySessions(),
+		TotalFocusMinutes: m.appStats.GetTotalFocusMinutes(),
+		LastSessionDate:   m.appStats.GetLastSessionDate(),
+		Badge:             m.appStats.GetBadge(),
+		BadgeDescription:  m.appStats.GetBadgeDescription(),
+	}
+}
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// lineInput is a line of text typed into a panel, such as a task title
+type lineInput struct {
+	active bool
+	text   string
+}
+
+// start begins editing text
+func (in *lineInput) start(text string) {
+	in.active = true
+	in.text = text
+}
+
+// update applies a key, reporting whether enter submitted the text. esc
+// cancels; either way the input is no longer active afterwards.
+func (in *lineInput) update(msg tea.KeyMsg) (submitted bool) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		in.active = false
+		return true
+	case tea.KeyEsc, tea.KeyCtrlC:
+		in.active = false
+	case tea.KeyBackspace:
+		if runes := []rune(in.text); len(runes) > 0 {
+			in.text = string(runes[:len(runes)-1])
+		}
+	case tea.KeySpace:
+		in.text += " "
+	case tea.KeyRunes:
+		in.text += string(msg.Runes)
+	}
+	return false
+}
+
+// view shows the text with a cursor
+func (in *lineInput) view() string {
+	return in.text + "█"
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"zoneout/control"
+	"zoneout/stats"
+)
+
+// logInterruption counts an interruption of the focus session and asks for
+// an optional note
+func (m *Model) logInterruption(kind string) {
+	m.interruptions = append(m.focusInterruptions(m.pomodoro.PhaseStarted), stats.Interruption{
+		At:   time.Now(),
+		Kind: kind,
+	})
+	m.interruptNote.start("")
+}
+
+// handleInterruptNote adds the note typed for the last interruption; esc
+// keeps the interruption without one
+func (m *Model) handleInterruptNote(msg tea.KeyMsg) {
+	submitted := m.interruptNote.update(msg)
+	if len(m.interruptions) == 0 {
+		return
+	}
+	last := &m.interruptions[len(m.interruptions)-1]
+	if submitted {
+		last.Note = m.interruptNote.text
+	}
+	if m.daemon != nil && !m.interruptNote.active {
+		// Attached, the daemon logs it once the note is settled
+		kind, note := last.Kind, last.Note
+		m.interruptions = nil
+		if err := m.interrupt(kind, note); err != nil {
+			m.showToast(err.Error())
+		}
+	}
+}
+
+// focusInterruptions are the interruptions logged since start, those of
+// earlier focus sessions are dropped
+func (m *Model) focusInterruptions(start time.Time) []stats.Interruption {
+	var current []stats.Interruption
+	for _, in := range m.interruptions {
+		if !in.At.Before(start) {
+			current = append(current, in)
+		}
+	}
+	return current
+}
+
+// interruptionsLine counts the interruptions of the current focus session
+// for the dashboard, empty when there are none
+func interruptionsLine(s control.Status) string {
+	if s.InternalInterruptions+s.ExternalInterruptions == 0 {
+		return ""
+	}
+	return fmt.Sprintf("⚡ Interruptions: %d internal, %d external", s.InternalInterruptions, s.ExternalInterruptions)
+}
+
+func (m *Model) renderInterruptNote() string {
+	kind := "Internal"
+	if n := len(m.interruptions); n > 0 && m.interruptions[n-1].Kind == stats.InterruptionExternal {
+		kind = "External"
+	}
+	return fmt.Sprintf("%s interruption logged. Note: %s\n(enter - Save | esc - Skip)", kind, m.interruptNote.view())
+}
@@ -2,15 +2,22 @@ package ui
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"zoneout/audio"
+	"zoneout/calendar"
 	"zoneout/config"
+	"zoneout/control"
 	"zoneout/models"
+	"zoneout/orgclock"
 	"zoneout/stats"
+	"zoneout/statusbar"
+	"zoneout/tasks"
+	"zoneout/teamsync"
 )
 
 type TickMsg time.Time
@@ -25,14 +32,75 @@ type Model struct {
 	motdManager    interface{} // MOTDManager interface from main package
 	selectedMP3    int
 	availableMP3s  []string
+	tonePresets    []audio.ToneConfig // Listed in the audio menu after the MP3s
 	showAudioMenu  bool
 	showHelp       bool
 	lastTickTime   time.Time
 	width          int
 	height         int
 	lastPhaseMode  models.Mode
+	audioDiag      audio.Diagnostics
+	toastMessage   string
+	toastUntil     time.Time
+	audioRetryAt   time.Time // Don't auto-restart audio before this after a failure
+	windDownUntil  time.Time // Ambient audio fades out and stops at this time
+	windDownLabel  string
+	sleepPreset    int // Index into sleepPresets, -1 when the sleep timer is off
+	statusFile     string // Kept up to date for status bars, empty disables
+	lastStatus     control.Status
+	statusWritten  time.Time
+	errorSources   []ErrorSource // Background workers whose failures are shown as toasts
+	teamHost       *teamsync.Host // Set when other instances follow this timer
+	teamPeer       *teamsync.Peer // Set when this timer follows another instance
+	teamConnected  bool
+	teamPeers      []string
+	taskSource     tasks.Source // nil disables the task panel
+	taskTotals     map[string]stats.TaskTotal
+	showTasks      bool
+	selectedTask   int
+	taskInput      lineInput
+	editTaskID     string // Task being edited, empty when adding one
+	sessionTags    []string // Recorded with each focus session
+	knownTags      []string // Listed in the tag picker
+	showTags       bool
+	selectedTag    int
+	tagInput       lineInput
+	showReport     bool
+	reportPeriod   int // Index into reportPeriods
+	reportRecords  []stats.SessionRecord
+	interruptions  []stats.Interruption // Logged during the current focus session
+	interruptNote  lineInput
+	orgClock       *orgclock.File // Focus sessions are clocked here, nil disables
+	calendar       *calendar.Calendar // Focus phases end before its meetings, nil disables
+	calendarSeen   time.Time          // PhaseStarted of the phase last checked against the calendar
+	recordedFocus  time.Time          // End of the last focus block added to the stats
+	showPlan       bool
+	planInput      lineInput
+	planStartedOn  string // Day the plan last started at its start time
+	daemon         *daemonLink // Set when attached to a daemon, which runs the timer
 }
 
+// ErrorSource is a background worker, such as event hooks or webhooks,
+// whose failures the dashboard reports
+type ErrorSource interface {
+	TakeError() error
+}
+
+// sleepPresets are the durations the sleep timer key cycles through
+var sleepPresets = []time.Duration{15 * time.Minute, 30 * time.Minute, 45 * time.Minute, 60 * time.Minute, 90 * time.Minute}
+
+// defaultWindDown is used when winding down on demand without a configured length
+const defaultWindDown = 10 * time.Minute
+
+// toastDuration is how long a non-blocking error toast stays on screen
+const toastDuration = 5 * time.Second
+
+// statusCheckInterval limits how often the status file is compared and rewritten
+const statusCheckInterval = 250 * time.Millisecond
+
+// audioRetryDelay throttles automatic audio restarts after a playback failure
+const audioRetryDelay = 10 * time.Second
+
 func NewModel(pomodoro *models.Pomodoro, audioPlayer *audio.AudioPlayer, appStats *stats.Stats, appConfig *config.Config, motdManager interface{}) *Model {
 	m := &Model{
 		pomodoro:       pomodoro,
@@ -43,12 +111,25 @@ func NewModel(pomodoro *models.Pomodoro, audioPlayer *audio.AudioPlayer, appStat
 		selectedMP3:    0,
 		lastTickTime:   time.Now(),
 		lastPhaseMode:  models.ModeIdle,
+		sleepPreset:    -1,
 	}
 	m.availableMP3s = audioPlayer.GetAvailableMP3s()
+	m.audioDiag = audioPlayer.Diagnostics()
+	m.tonePresets = append(m.tonePresets, audio.DefaultTonePresets...)
+	if appConfig != nil {
+		if carrier, beat := appConfig.GetCustomTone(); carrier > 0 && beat > 0 {
+			m.tonePresets = append(m.tonePresets,
+				audio.ToneConfig{Name: fmt.Sprintf("Custom binaural %gHz", beat), Kind: audio.ToneBinaural, Carrier: carrier, Beat: beat},
+				audio.ToneConfig{Name: fmt.Sprintf("Custom isochronic %gHz", beat), Kind: audio.ToneIsochronic, Carrier: carrier, Beat: beat})
+		}
+	}
 	return m
 }
 
 func (m *Model) Init() tea.Cmd {
+	if m.daemon != nil {
+		return m.pollDaemon(0)
+	}
 	return tea.Batch(
 		m.tickCmd(),
 	)
@@ -64,10 +145,28 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		return m.handleKeyPress(msg)
+	case control.CommandMsg:
+		return m, m.handleCommand(msg)
+	case taskAddedMsg:
+		m.taskAdded(msg)
+	case daemonStatusMsg:
+		m.daemonReplied(msg.resp, msg.err)
+		// Keep polling while disconnected too, the daemon may come back
+		return m, m.pollDaemon(daemonPollInterval)
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 	case TickMsg:
+		// Surface playback failures from background players
+		if err := m.audioPlayer.TakeError(); err != nil {
+			m.showAudioError(err)
+		}
+		for _, src := range m.errorSources {
+			if err := src.TakeError(); err != nil {
+				m.showToast(err.Error())
+			}
+		}
+
 		// Check if MOTD needs refresh (every 24 hours)
 		if m.motdManager != nil {
 			if motd, ok := m.motdManager.(interface{ NeedsRefresh() bool; Refresh() }); ok {
@@ -77,111 +176,445 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		m.checkPlanStart(time.Now())
+
 		if m.pomodoro.IsRunning {
 			now := time.Now()
 			delta := now.Sub(m.lastTickTime)
 			m.lastTickTime = now
 
 			previousMode := m.pomodoro.CurrentMode
+			m.timerAdvanced(previousMode, m.pomodoro.Tick(delta))
+			m.checkCalendar()
+		}
 
-			if m.pomodoro.Tick(delta) {
-				// Phase completed (all sessions done)
-				m.audioPlayer.Stop()
-			}
-
-			// Check if we just transitioned FROM focus to break (focus session just completed)
-			if previousMode == models.ModeFocus && m.pomodoro.CurrentMode == models.ModeBreak {
-				// Focus session just completed, add to stats (25 minutes per session)
-				m.appStats.AddSession(25)
-			}
+		if m.teamHost != nil {
+			m.teamHost.Publish(m.pomodoro.State())
+		}
 
-			// Update the last phase mode
-			if m.pomodoro.CurrentMode != models.ModeIdle {
-				m.lastPhaseMode = m.pomodoro.CurrentMode
-			}
+		m.writeStatusFile()
 
-			// Manage audio based on mode
-			m.updateAudioMode()
+		// Wind-down or sleep timer finished, the player has faded out by now
+		if !m.windDownUntil.IsZero() && !m.isWindingDown() {
+			m.windDownUntil = time.Time{}
+			m.sleepPreset = -1
 		}
 		return m, m.tickCmd()
+	case teamsync.StateMsg:
+		m.followHost(msg.State)
+	case teamsync.ConnMsg:
+		if msg.Connected != m.teamConnected {
+			if msg.Connected {
+				m.showToast("Connected to the team host")
+			} else if msg.Err != nil {
+				m.showToast(fmt.Sprintf("Lost the team host: %v", msg.Err))
+			}
+		} else if msg.Connected && msg.Err != nil {
+			m.showToast(fmt.Sprintf("Team host: %v", msg.Err))
+		}
+		m.teamConnected = msg.Connected
 	case PhaseCompleteMsg:
 		// Handle phase completion
 	case RescanMP3sMsg:
 		if err := m.audioPlayer.ScanWhitenoiseDirectory(); err != nil {
-			// Log error
+			m.showToast(fmt.Sprintf("Failed to rescan sounds: %v", err))
 		}
 		m.availableMP3s = m.audioPlayer.GetAvailableMP3s()
+		m.audioDiag = m.audioPlayer.Diagnostics()
 	}
 
 	return m, nil
 }
 
+// timerAdvanced applies the effects of the timer moving on, whether it
+// ticked or followed a team host. cycleDone is true when all sessions are
+// done.
+func (m *Model) timerAdvanced(previousMode models.Mode, cycleDone bool) {
+	if cycleDone {
+		// Phase completed (all sessions done), let the ambient audio
+		// fade out instead of cutting it off if configured
+		if windDown := m.configWindDown(); windDown > 0 {
+			m.startWindDown(windDown, "Winding down")
+		} else {
+			m.audioPlayer.Stop()
+		}
+	}
+
+	// Check if a focus block just ended, whatever the plan has next
+	if previousMode == models.ModeFocus && !m.pomodoro.LastFocus.End.Equal(m.recordedFocus) {
+		// Focus session just completed, add to stats
+		m.recordedFocus = m.pomodoro.LastFocus.End
+		m.recordFocus()
+	}
+
+	// Update the last phase mode
+	if m.pomodoro.CurrentMode != models.ModeIdle {
+		m.lastPhaseMode = m.pomodoro.CurrentMode
+	}
+
+	// Manage audio based on mode
+	m.updateAudioMode()
+}
+
 func (m *Model) updateAudioMode() {
 	// Only play audio during FOCUS mode
 	if m.pomodoro.CurrentMode == models.ModeFocus {
 		// Resume audio if it was playing before and we're back to focus
-		if !m.audioPlayer.IsPlaying() && m.pomodoro.IsRunning {
+		if !m.audioPlayer.IsPlaying() && m.pomodoro.IsRunning && time.Now().After(m.audioRetryAt) {
 			// Try to resume or restart the last selected audio if available
 			if len(m.availableMP3s) > 0 {
 				currentMP3 := m.audioPlayer.GetCurrentMP3()
 				if currentMP3 == "" {
 					// No audio selected yet, play the first one
-					m.audioPlayer.PlayMP3(m.availableMP3s[0])
+					m.playMP3(m.availableMP3s[0])
 				} else {
 					// Resume the previously selected audio
-					m.audioPlayer.PlayMP3(currentMP3)
+					m.playMP3(currentMP3)
 				}
 			}
 		}
+		// Layer the generated tone, if one is selected, over the whitenoise
+		if m.audioPlayer.SelectedTone() != nil && !m.audioPlayer.IsTonePlaying() &&
+			m.pomodoro.IsRunning && time.Now().After(m.audioRetryAt) {
+			if err := m.audioPlayer.PlayTone(); err != nil {
+				m.showAudioError(err)
+			}
+		}
 	} else {
-		// Pause audio during BREAK or IDLE modes
-		if m.audioPlayer.IsPlaying() {
+		// Pause audio during BREAK or IDLE modes, unless it is winding down
+		if m.audioPlayer.IsPlaying() && !m.isWindingDown() {
 			m.audioPlayer.Pause()
 		}
 	}
 }
 
+// configWindDown returns the configured wind-down length, 0 if disabled
+func (m *Model) configWindDown() time.Duration {
+	if m.appConfig == nil {
+		return 0
+	}
+	return m.appConfig.GetWindDown()
+}
+
+// startWindDown keeps ambient audio playing for total and fades it out at the end
+func (m *Model) startWindDown(total time.Duration, label string) {
+	if len(m.availableMP3s) == 0 {
+		return
+	}
+
+	fade := time.Minute
+	if m.appConfig != nil {
+		fade = m.appConfig.GetFadeOut()
+	}
+	if fade > total {
+		fade = total
+	}
+
+	sound := m.audioPlayer.GetCurrentMP3()
+	if sound == "" {
+		sound = m.availableMP3s[0]
+	}
+	if err := m.audioPlayer.PlayWithFadeOut(sound, total-fade, fade); err != nil {
+		m.showAudioError(err)
+		return
+	}
+
+	m.windDownUntil = time.Now().Add(total)
+	m.windDownLabel = label
+}
+
+// cancelWindDown stops a wind-down or sleep timer in progress
+func (m *Model) cancelWindDown() {
+	if m.isWindingDown() {
+		m.audioPlayer.Stop()
+	}
+	m.windDownUntil = time.Time{}
+	m.sleepPreset = -1
+}
+
+func (m *Model) isWindingDown() bool {
+	return time.Now().Before(m.windDownUntil)
+}
+
+// SetStatusFile makes the model keep a status file up to date for status bars
+func (m *Model) SetStatusFile(path string) {
+	m.statusFile = path
+}
+
+// SetOrgClock adds a CLOCK line to an Org file for every focus session
+func (m *Model) SetOrgClock(file *orgclock.File) {
+	m.orgClock = file
+}
+
+// SetTeamHost shares this timer with the peers connected to host
+func (m *Model) SetTeamHost(host *teamsync.Host) {
+	m.teamHost = host
+}
+
+// SetTeamPeer makes this timer follow a team host. Timer commands are
+// forwarded to the host instead of being applied locally.
+func (m *Model) SetTeamPeer(peer *teamsync.Peer) {
+	m.teamPeer = peer
+	m.pomodoro.Following = true
+}
+
+// followHost applies a state received from the team host
+func (m *Model) followHost(s teamsync.State) {
+	m.teamConnected = true
+	m.teamPeers = s.Peers
+
+	previousMode := m.pomodoro.CurrentMode
+	wasPaused := m.pomodoro.IsPaused
+	cycleDone := m.pomodoro.Follow(s.PhaseState())
+	m.lastTickTime = time.Now()
+
+	switch {
+	case m.pomodoro.IsPaused && !wasPaused:
+		m.audioPlayer.Pause()
+	case wasPaused && m.pomodoro.IsRunning:
+		m.audioPlayer.Resume()
+	case previousMode != models.ModeIdle && m.pomodoro.CurrentMode == models.ModeIdle && !cycleDone:
+		// The host reset the cycle
+		m.cancelWindDown()
+		m.audioPlayer.Stop()
+	}
+	if previousMode == models.ModeIdle && m.pomodoro.CurrentMode != models.ModeIdle {
+		m.cancelWindDown()
+	}
+	m.timerAdvanced(previousMode, cycleDone)
+}
+
+// AddErrorSource shows failures from a background worker as toasts
+func (m *Model) AddErrorSource(src ErrorSource) {
+	m.errorSources = append(m.errorSources, src)
+}
+
+// writeStatusFile rewrites the status file when the status changes, and at
+// least every second so readers can tell the instance is alive
+func (m *Model) writeStatusFile() {
+	if m.statusFile == "" || time.Since(m.statusWritten) < statusCheckInterval {
+		return
+	}
+	status := m.status()
+	if reflect.DeepEqual(status, m.lastStatus) && time.Since(m.statusWritten) < time.Second {
+		return
+	}
+	if err := statusbar.WriteFile(m.statusFile, status); err != nil {
+		// Don't retry every tick if the file can't be written
+		m.showToast(err.Error())
+		m.statusFile = ""
+		return
+	}
+	m.lastStatus = status
+	m.statusWritten = time.Now()
+}
+
+// playMP3 starts playback and reports a failure as a toast
+func (m *Model) playMP3(path string) {
+	if err := m.audioPlayer.PlayMP3(path); err != nil {
+		m.showAudioError(err)
+	}
+}
+
+// showAudioError reports a playback failure and backs off automatic restarts
+func (m *Model) showAudioError(err error) {
+	// The player also queues the error for polling; drop it so it isn't shown twice
+	m.audioPlayer.TakeError()
+	m.audioRetryAt = time.Now().Add(audioRetryDelay)
+	m.audioDiag.LastError = err
+	m.showToast(fmt.Sprintf("Audio error: %v", err))
+}
+
+// showToast displays a message that disappears on its own
+func (m *Model) showToast(message string) {
+	m.toastMessage = message
+	m.toastUntil = time.Now().Add(toastDuration)
+}
+
+// audioMenuLength counts the MP3s, the tone presets and the "tone off" entry
+func (m *Model) audioMenuLength() int {
+	return len(m.availableMP3s) + len(m.tonePresets) + 1
+}
+
+// selectAudioMenuItem plays an MP3 or selects a tone to layer over it
+func (m *Model) selectAudioMenuItem(index int) {
+	if m.daemon != nil {
+		name := "off" // The entry after the tones
+		if index < len(m.availableMP3s) {
+			name = m.availableMP3s[index]
+		} else if i := index - len(m.availableMP3s); i < len(m.tonePresets) {
+			name = m.tonePresets[i].Name
+		}
+		if err := m.selectSound(name); err != nil {
+			m.showToast(err.Error())
+		}
+		return
+	}
+	if index < len(m.availableMP3s) {
+		m.playMP3(m.availableMP3s[index])
+		return
+	}
+
+	var tone *audio.ToneConfig
+	if i := index - len(m.availableMP3s); i < len(m.tonePresets) {
+		tone = &m.tonePresets[i]
+	}
+	if err := m.audioPlayer.SelectTone(tone); err != nil {
+		m.showAudioError(err)
+	}
+}
+
+// closePanels hides the audio menu, help, task list, tag picker, report
+// and plan
+func (m *Model) closePanels() {
+	m.showAudioMenu = false
+	m.showHelp = false
+	m.showTasks = false
+	m.showTags = false
+	m.showReport = false
+	m.reportRecords = nil
+	m.showPlan = false
+}
+
 func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.interruptNote.active {
+		m.handleInterruptNote(msg)
+		return m, nil
+	}
+	// The task panel gets the keys it uses first, all of them while typing
+	if m.showTasks {
+		if m.taskInput.active {
+			return m, m.handleTaskInput(msg)
+		}
+		if m.handleTaskKey(msg) {
+			return m, nil
+		}
+	}
+	if m.showTags {
+		if m.tagInput.active {
+			m.handleTagInput(msg)
+			return m, nil
+		}
+		if m.handleTagKey(msg) {
+			return m, nil
+		}
+	}
+	if m.showReport && m.handleReportKey(msg) {
+		return m, nil
+	}
+	if m.showPlan {
+		if m.planInput.active {
+			m.handlePlanInput(msg)
+			return m, nil
+		}
+		if m.handlePlanKey(msg) {
+			return m, nil
+		}
+	}
+
 	switch msg.String() {
-	case "q", "ctrl+c":
-		m.audioPlayer.Stop()
+	case "q", "ctrl+c": // attached, only detach: the daemon keeps running
+		if m.daemon == nil {
+			m.audioPlayer.Stop()
+		}
 		return m, tea.Quit
 
-	case " ": // space - Start/Pause
-		if m.pomodoro.CurrentMode == models.ModeIdle {
-			m.pomodoro.Start()
-			m.lastTickTime = time.Now()
-		} else if m.pomodoro.IsRunning && !m.pomodoro.IsPaused {
-			m.pomodoro.Pause()
-			m.audioPlayer.Pause()
-			m.lastTickTime = time.Now() // Reset time to avoid jump on resume
-		} else if m.pomodoro.IsPaused {
-			m.pomodoro.Resume()
-			m.audioPlayer.Resume()
-			m.lastTickTime = time.Now() // Reset time to avoid jump on resume
+	case "Q": // stop the daemon too
+		if m.daemon != nil {
+			m.forwardToDaemon(control.Request{Command: control.CommandShutdown})
+			return m, tea.Quit
 		}
 
+	case " ": // space - Start/Pause
+		m.toggle()
+
 	case "R": // reset cycle
-		m.pomodoro.Stop()
-		m.audioPlayer.Stop()
+		m.reset()
 		m.showAudioMenu = false
 
+	case "w": // wind down now: end the cycle and let the audio fade out
+		if m.daemon != nil {
+			m.showToast(attachedOnlyTimer)
+			break
+		}
+		if m.teamPeer != nil {
+			m.showToast("The team host controls the timer")
+			break
+		}
+		windDown := m.configWindDown()
+		if windDown == 0 {
+			windDown = defaultWindDown
+		}
+		m.pomodoro.Reset()
+		m.sleepPreset = -1
+		m.startWindDown(windDown, "Winding down")
+
+	case "z": // cycle the sleep timer presets, then off
+		if m.daemon != nil {
+			m.showToast(attachedOnlyTimer)
+			break
+		}
+		if m.teamPeer != nil {
+			m.showToast("The team host controls the timer")
+			break
+		}
+		m.sleepPreset++
+		if m.sleepPreset >= len(sleepPresets) {
+			m.cancelWindDown()
+			break
+		}
+		m.pomodoro.Reset()
+		preset := m.sleepPreset
+		m.startWindDown(sleepPresets[preset], "Sleep timer")
+		m.sleepPreset = preset
+
 	case "r": // reset session
+		if m.daemon != nil {
+			m.showToast(attachedOnlyTimer)
+			break
+		}
+		if m.teamPeer != nil {
+			m.showToast("The team host controls the timer")
+			break
+		}
 		m.pomodoro.RemainingTime = m.pomodoro.TotalTime
 		m.pomodoro.PlayStartSound()
 		m.lastTickTime = time.Now()
 
 	case ">": // skip to next phase
-		if m.pomodoro.IsRunning || m.pomodoro.IsPaused {
-			m.pomodoro.NextPhase()
-			// Update audio mode after skipping
-			m.updateAudioMode()
-		}
+		m.skip()
 
 	case "a":
-		if len(m.availableMP3s) > 0 {
-			m.showAudioMenu = !m.showAudioMenu
+		show := !m.showAudioMenu
+		m.closePanels()
+		if show && m.daemon != nil {
+			m.loadDaemonSounds()
 		}
+		m.showAudioMenu = show
+
+	case "t":
+		if m.daemon != nil && !m.showTasks {
+			if err := m.loadDaemonTasks(); err != nil {
+				m.showToast(err.Error())
+				break
+			}
+		}
+		if m.taskSource != nil {
+			show := !m.showTasks
+			m.closePanels()
+			m.showTasks = show
+		}
+
+	case "#": // tags for this focus session
+		m.openTags()
+
+	case "p": // focus time per tag
+		m.openReport()
+
+	case "b": // the planned blocks of the day
+		show := !m.showPlan
+		m.closePanels()
+		m.showPlan = show
 
 	case "up":
 		if m.showAudioMenu && m.selectedMP3 > 0 {
@@ -189,13 +622,14 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case "down":
-		if m.showAudioMenu && m.selectedMP3 < len(m.availableMP3s)-1 {
+		if m.showAudioMenu && m.selectedMP3 < m.audioMenuLength()-1 {
 			m.selectedMP3++
 		}
 
 	case "enter":
-		if m.showAudioMenu && len(m.availableMP3s) > 0 {
-			m.audioPlayer.PlayMP3(m.availableMP3s[m.selectedMP3])
+		if m.showAudioMenu {
+			m.audioRetryAt = time.Time{} // Explicit choice, retry right away
+			m.selectAudioMenuItem(m.selectedMP3)
 			m.showAudioMenu = false
 		}
 
@@ -207,8 +641,9 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case "h", "?":
-		m.showHelp = !m.showHelp
-		m.showAudioMenu = false // Close audio menu if help opens
+		show := !m.showHelp
+		m.closePanels() // Close audio menu if help opens
+		m.showHelp = show
 
 	case "m": // New random MOTD
 		if m.motdManager != nil {
@@ -218,18 +653,19 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case "+", "=": // Volume up
-		newVolume := m.audioPlayer.VolumeUp()
-		if m.appConfig != nil {
-			m.appConfig.SetVolume(newVolume)
+		m.setVolume(m.status().Volume + 0.1)
+
+	case "'": // internal interruption
+		if m.pomodoro.CurrentMode == models.ModeFocus {
+			m.logInterruption(stats.InterruptionInternal)
 		}
-		// Volume will be applied on next audio start
 
-	case "-", "_": // Volume down
-		newVolume := m.audioPlayer.VolumeDown()
-		if m.appConfig != nil {
-			m.appConfig.SetVolume(newVolume)
+	case "-", "_": // Volume down, "-" logs an external interruption while focusing
+		if msg.String() == "-" && m.pomodoro.CurrentMode == models.ModeFocus {
+			m.logInterruption(stats.InterruptionExternal)
+			break
 		}
-		// Volume will be applied on next audio start
+		m.setVolume(m.status().Volume - 0.1)
 	}
 
 	return m, nil
@@ -241,11 +677,25 @@ func (m *Model) View() string {
 	}
 
 	content := m.renderDashboard()
+	if m.interruptNote.active {
+		content += "\n\n" + lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFD93D")).
+			PaddingLeft(2).
+			Render(m.renderInterruptNote())
+	}
 
 	if m.showHelp {
 		content += "\n\n" + m.renderHelp()
 	} else if m.showAudioMenu {
 		content += "\n\n" + m.renderAudioMenu()
+	} else if m.showTasks {
+		content += "\n\n" + m.renderTasks()
+	} else if m.showTags {
+		content += "\n\n" + m.renderTags()
+	} else if m.showReport {
+		content += "\n\n" + m.renderReport()
+	} else if m.showPlan {
+		content += "\n\n" + m.renderPlan()
 	}
 
 	return content
@@ -259,9 +709,37 @@ func (m *Model) renderDashboard() string {
 		Bold(true).
 		Foreground(lipgloss.Color("#FF6B6B")).
 		Padding(1, 2)
-	sb.WriteString(titleStyle.Render("🍅 ZONEOUT"))
+	title := "🍅 ZONEOUT"
+	if m.daemon != nil {
+		title += " (attached)"
+	}
+	sb.WriteString(titleStyle.Render(title))
 	sb.WriteString("\n\n")
 
+	// Attached, everything shown comes from the daemon
+	if m.daemon != nil && (m.daemon.err != nil || !m.daemon.connected) {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF6B6B")).
+			PaddingLeft(2)
+		sb.WriteString(errorStyle.Render("Disconnected from the zoneout daemon, retrying..."))
+		sb.WriteString("\n\n")
+		if !m.daemon.connected {
+			return sb.String()
+		}
+	}
+	s := m.status()
+
+	// Error toast (expires on its own, never blocks input)
+	if m.toastMessage != "" && time.Now().Before(m.toastUntil) {
+		toastStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFFFF")).
+			Background(lipgloss.Color("#C0392B")).
+			Padding(0, 1).
+			MarginLeft(2)
+		sb.WriteString(toastStyle.Render("⚠ " + m.toastMessage))
+		sb.WriteString("\n\n")
+	}
+
 	// Mode display
 	modeStr := m.pomodoro.GetModeString()
 	modeColor := "#FFD93D"
@@ -275,6 +753,9 @@ func (m *Model) renderDashboard() string {
 		Bold(true).
 		Foreground(lipgloss.Color(modeColor)).
 		PaddingLeft(2)
+	if label := m.pomodoro.BlockLabel; label != "" && !strings.EqualFold(label, modeStr) {
+		modeStr += " · " + label
+	}
 	sb.WriteString(modeStyle.Render(fmt.Sprintf("Mode: %s", modeStr)))
 	sb.WriteString("\n\n")
 
@@ -290,12 +771,12 @@ func (m *Model) renderDashboard() string {
 		BorderForeground(lipgloss.Color("#00D9FF"))
 
 	// Create a large ASCII-style display
-	largeTimer := m.createLargeTimer(timeStr)
+	largeTimer := createLargeTimer(timeStr)
 	sb.WriteString(largeTimerStyle.Render(largeTimer))
 	sb.WriteString("\n\n")
 
 	// Progress bar
-	progressBar := m.createProgressBar()
+	progressBar := createProgressBar(m.pomodoro)
 	sb.WriteString(progressBar)
 	sb.WriteString("\n\n")
 
@@ -304,12 +785,57 @@ func (m *Model) renderDashboard() string {
 		Foreground(lipgloss.Color("#A0E7E5")).
 		PaddingLeft(2)
 	sb.WriteString(sessionStyle.Render(fmt.Sprintf("Session %d of %d | Completed Today: %d",
-		m.pomodoro.CurrentSession, m.pomodoro.Session.TotalSessions, m.appStats.GetTodaySessions())))
+		m.pomodoro.CurrentSession, m.pomodoro.TotalSessions, s.TodaySessions)))
 	sb.WriteString("\n\n")
 
+	// Active task
+	if task := m.activeTaskLine(s); task != "" {
+		taskStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFFFF")).
+			PaddingLeft(2)
+		sb.WriteString(taskStyle.Render(task))
+		sb.WriteString("\n\n")
+	}
+
+	// Interruptions of this focus session
+	if line := interruptionsLine(s); line != "" {
+		interruptStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF6B6B")).
+			PaddingLeft(2)
+		sb.WriteString(interruptStyle.Render(line))
+		sb.WriteString("\n\n")
+	}
+
+	// Next block of the plan
+	if line := m.upNextLine(); line != "" {
+		planStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#A0E7E5")).
+			PaddingLeft(2)
+		sb.WriteString(planStyle.Render(line))
+		sb.WriteString("\n\n")
+	}
+
+	// Meetings
+	if line := calendarLine(s); line != "" {
+		calendarStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFD93D")).
+			PaddingLeft(2)
+		sb.WriteString(calendarStyle.Render(line))
+		sb.WriteString("\n\n")
+	}
+
+	// Session tags
+	if tags := tagsLine(s); tags != "" {
+		tagStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#A0E7E5")).
+			PaddingLeft(2)
+		sb.WriteString(tagStyle.Render(tags))
+		sb.WriteString("\n\n")
+	}
+
 	// Badge
-	badge := m.appStats.GetBadge()
-	badgeDesc := m.appStats.GetBadgeDescription()
+	badge := s.Badge
+	badgeDesc := s.BadgeDescription
 
 	badgeStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#FFD93D")).
@@ -336,39 +862,141 @@ func (m *Model) renderDashboard() string {
 	sb.WriteString(statusStyle.Render(statusStr))
 	sb.WriteString("\n\n")
 
+	// Wind-down / sleep timer countdown
+	if s.WindDown != "" {
+		windDownStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#B39DDB")).
+			PaddingLeft(2)
+		sb.WriteString(windDownStyle.Render(fmt.Sprintf("🌙 %s: %s left",
+			s.WindDown, formatDuration(time.Duration(s.WindDownSeconds)*time.Second))))
+		sb.WriteString("\n\n")
+	}
+
+	// Team sync
+	if team := teamStatus(s.Team); team != "" {
+		teamStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#A0E7E5")).
+			PaddingLeft(2)
+		sb.WriteString(teamStyle.Render(team))
+		sb.WriteString("\n\n")
+	}
+
 	// Volume level
-	volumePercent := int(m.audioPlayer.GetVolume() * 100)
+	volumePercent := int(s.Volume * 100)
 	volumeStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#A0E7E5")).
 		PaddingLeft(2)
 	sb.WriteString(volumeStyle.Render(fmt.Sprintf("Volume: %d%%", volumePercent)))
+	sb.WriteString("\n")
+	sb.WriteString(renderAudioStatus(s))
 	sb.WriteString("\n\n")
 
 	// MOTD Message
-	if m.motdManager != nil {
-		if motd, ok := m.motdManager.(interface{ GetMessage() string }); ok && motd != nil {
-			message := motd.GetMessage()
-			if message != "" {
-				motdStyle := lipgloss.NewStyle().
-					Foreground(lipgloss.Color("#FFD93D")).
-					PaddingLeft(2).
-					Italic(true)
-				sb.WriteString(motdStyle.Render(fmt.Sprintf("💬 %s", message)))
-				sb.WriteString("\n\n")
-			}
-		}
+	if s.Message != "" {
+		motdStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFD93D")).
+			PaddingLeft(2).
+			Italic(true)
+		sb.WriteString(motdStyle.Render(fmt.Sprintf("💬 %s", s.Message)))
+		sb.WriteString("\n\n")
 	}
 
 	// Help hint
 	hintStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#666666")).
 		PaddingLeft(2)
-	sb.WriteString(hintStyle.Render("Press 'h' or '?' for help"))
+	hint := "Press 'h' or '?' for help"
+	if m.daemon != nil {
+		hint += " | 'q' to detach"
+	}
+	sb.WriteString(hintStyle.Render(hint))
 
 	return sb.String()
 }
 
-func (m *Model) createLargeTimer(timeStr string) string {
+// team describes the team timer for the status, nil when not in a team
+func (m *Model) team() *control.Team {
+	switch {
+	case m.teamHost != nil:
+		return &control.Team{Host: true, Addr: m.teamHost.Addr(), Connected: true, Peers: m.teamHost.Peers()}
+	case m.teamPeer != nil:
+		return &control.Team{Addr: m.teamPeer.Addr(), Connected: m.teamConnected, Peers: m.teamPeers}
+	}
+	return nil
+}
+
+// followsTeamHost reports whether the timer, here or in the daemon
+// attached to, follows a team host
+func (m *Model) followsTeamHost() bool {
+	if m.daemon == nil {
+		return m.teamPeer != nil
+	}
+	t := m.daemon.status.Team
+	return t != nil && !t.Host
+}
+
+// teamStatus describes the team timer, empty when not in a team
+func teamStatus(t *control.Team) string {
+	switch {
+	case t == nil:
+		return ""
+	case t.Host:
+		if len(t.Peers) == 0 {
+			return fmt.Sprintf("👥 Hosting team timer on %s, waiting for peers", t.Addr)
+		}
+		return fmt.Sprintf("👥 Hosting team timer on %s: %s", t.Addr, strings.Join(t.Peers, ", "))
+	case !t.Connected:
+		return fmt.Sprintf("👥 Reconnecting to team host %s...", t.Addr)
+	}
+	return fmt.Sprintf("👥 Following team host %s: %s", t.Addr, strings.Join(t.Peers, ", "))
+}
+
+// renderAudioStatus shows the detected player, embedded asset state and last error
+func renderAudioStatus(s control.Status) string {
+	statusStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#666666")).
+		PaddingLeft(2)
+	errorStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FF6B6B")).
+		PaddingLeft(2)
+
+	player := s.Player
+	if player == "" {
+		return errorStyle.Render("Audio: no player found (run 'zoneout doctor')")
+	}
+
+	embedded := "embedded ✓"
+	if !s.EmbeddedSounds {
+		embedded = "embedded ✗"
+	}
+	status := fmt.Sprintf("Audio: %s | %s", player, embedded)
+	if s.Tone != "" {
+		status += " | tone: " + s.Tone
+	}
+	line := statusStyle.Render(status)
+
+	if s.AudioError != "" {
+		line += "\n" + errorStyle.Render(fmt.Sprintf("Last audio error: %s", s.AudioError))
+	}
+	return line
+}
+
+// formatDuration renders d as MM:SS, or H:MM:SS for an hour or more
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	d = d.Round(time.Second)
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+}
+
+func createLargeTimer(timeStr string) string {
 	// Create ASCII art numbers for the timer
 	digits := map[string][]string{
 		"0": {
@@ -463,17 +1091,17 @@ func (m *Model) createLargeTimer(timeStr string) string {
 	return strings.Join(lines, "\n")
 }
 
-func (m *Model) createProgressBar() string {
+func createProgressBar(p *models.Pomodoro) string {
 	// Bar dimensions - match timer width (approximately 50 chars)
 	barWidth := 50
 	var filledWidth int
 
 	// Calculate progress based on mode
-	if m.pomodoro.CurrentMode == models.ModeIdle || m.pomodoro.TotalTime == 0 {
+	if p.CurrentMode == models.ModeIdle || p.TotalTime == 0 {
 		// Show empty bar when idle
 		filledWidth = 0
 	} else {
-		progress := float64(m.pomodoro.TotalTime-m.pomodoro.RemainingTime) / float64(m.pomodoro.TotalTime)
+		progress := float64(p.TotalTime-p.RemainingTime) / float64(p.TotalTime)
 		if progress < 0 {
 			progress = 0
 		}
@@ -487,9 +1115,9 @@ func (m *Model) createProgressBar() string {
 
 	// Determine color based on mode
 	progressColor := "#666666" // Idle mode - dark gray
-	if m.pomodoro.CurrentMode == models.ModeFocus {
+	if p.CurrentMode == models.ModeFocus {
 		progressColor = "#FF6B6B" // Focus mode - red/orange
-	} else if m.pomodoro.CurrentMode == models.ModeBreak {
+	} else if p.CurrentMode == models.ModeBreak {
 		progressColor = "#6BCF7F" // Break mode - green
 	}
 
@@ -522,14 +1150,32 @@ func (m *Model) renderHelp() string {
 	sb.WriteString("─── HELP ───\n\n")
 	sb.WriteString("SPACE     Start/Pause\n")
 	sb.WriteString("R         Reset Cycle (back to idle)\n")
-	sb.WriteString("r         Reset Session (restart timer)\n")
+	if m.daemon == nil {
+		sb.WriteString("r         Reset Session (restart timer)\n")
+	}
 	sb.WriteString(">         Skip to next phase\n")
 	sb.WriteString("a         Toggle audio menu\n")
-	sb.WriteString("+/-       Volume Up/Down\n")
+	sb.WriteString("t         Toggle task list\n")
+	sb.WriteString("#         Tag this focus session\n")
+	sb.WriteString("p         Focus time per tag\n")
+	sb.WriteString("b         Plan the day's blocks\n")
+	sb.WriteString("+/-       Volume Up/Down (_ while focusing)\n")
+	sb.WriteString("' / -     Log internal/external interruption\n")
+	if m.daemon == nil {
+		sb.WriteString("w         Wind down (audio fades out)\n")
+		sb.WriteString("z         Sleep timer (15/30/45/60/90m, off)\n")
+	}
 	sb.WriteString("h / ?     Toggle help\n")
-	sb.WriteString("m         New random MOTD\n")
+	if m.daemon == nil {
+		sb.WriteString("m         New random MOTD\n")
+	}
 	sb.WriteString("ESC       Close menu\n")
-	sb.WriteString("q         Quit\n")
+	if m.daemon != nil {
+		sb.WriteString("q         Detach (timer keeps running)\n")
+		sb.WriteString("Q         Stop the daemon and quit\n")
+	} else {
+		sb.WriteString("q         Quit\n")
+	}
 
 	return menuStyle.Render(sb.String())
 }
@@ -548,22 +1194,37 @@ func (m *Model) renderAudioMenu() string {
 		sb.WriteString("No MP3 files found in ./whitenoise/\n")
 	} else {
 		for i, mp3 := range m.availableMP3s {
-			prefix := "  "
-			style := lipgloss.NewStyle()
-			if i == m.selectedMP3 {
-				prefix = "→ "
-				style = style.Bold(true).Foreground(lipgloss.Color("#FFD93D"))
-			}
 			// Extract filename from path
 			filename := mp3
 			if slashIdx := strings.LastIndex(mp3, "/"); slashIdx >= 0 {
 				filename = mp3[slashIdx+1:]
 			}
-			sb.WriteString(style.Render(prefix + filename + "\n"))
+			sb.WriteString(m.renderAudioMenuItem(i, filename))
 		}
 	}
 
+	// Generated tones play on top of the selected MP3
+	sb.WriteString("\n─── FOCUS TONES (headphones for binaural) ───\n\n")
+	selectedTone := m.status().Tone
+	for i, tone := range m.tonePresets {
+		label := tone.Name
+		if tone.Name == selectedTone {
+			label += " ♪"
+		}
+		sb.WriteString(m.renderAudioMenuItem(len(m.availableMP3s)+i, label))
+	}
+	sb.WriteString(m.renderAudioMenuItem(m.audioMenuLength()-1, "Tone off"))
 	sb.WriteString("\nenter - Select | ↑/↓ - Navigate | esc - Close\n")
 
 	return menuStyle.Render(sb.String())
 }
+
+func (m *Model) renderAudioMenuItem(index int, label string) string {
+	prefix := "  "
+	style := lipgloss.NewStyle()
+	if index == m.selectedMP3 {
+		prefix = "→ "
+		style = style.Bold(true).Foreground(lipgloss.Color("#FFD93D"))
+	}
+	return style.Render(prefix + label + "\n")
+}
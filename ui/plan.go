@@ -0,0 +1,208 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"zoneout/control"
+	"zoneout/models"
+)
+
+// Plan panel: the blocks of the day, when they're due and an editor
+
+// planStartWindow is how late past the plan's start time the timer still
+// starts it, so opening zoneout later in the day doesn't start it
+// unasked
+const planStartWindow = time.Minute
+
+// handlePlanKey handles a key while the plan is open, reporting whether it
+// was used
+func (m *Model) handlePlanKey(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "e":
+		if m.followsTeamHost() {
+			m.showToast("The team host plans the blocks")
+			break
+		}
+		m.planInput.start(m.pomodoro.Plan.String())
+	case "esc", "b":
+		m.showPlan = false
+	default:
+		return false
+	}
+	return true
+}
+
+// handlePlanInput applies the edited plan
+func (m *Model) handlePlanInput(msg tea.KeyMsg) {
+	if !m.planInput.update(msg) {
+		return
+	}
+	if err := m.setPlan(m.planInput.text); err != nil {
+		m.showToast(err.Error())
+		if strings.TrimSpace(m.planInput.text) != "" {
+			m.planInput.start(m.planInput.text) // Keep editing
+		}
+	}
+}
+
+// setPlan parses, applies and saves a plan; an empty one restores the
+// default cycle
+func (m *Model) setPlan(text string) error {
+	if m.daemon != nil {
+		return m.sendToDaemon(control.Request{Command: control.CommandSetPlan, Plan: text})
+	}
+	if m.teamPeer != nil {
+		return fmt.Errorf("the team host plans the blocks")
+	}
+	text = strings.TrimSpace(text)
+	plan := models.DefaultPlan()
+	if text != "" {
+		var err error
+		if plan, err = models.ParsePlan(text); err != nil {
+			return err
+		}
+		text = plan.String()
+	}
+	m.pomodoro.SetPlan(plan)
+	if m.appConfig != nil {
+		if err := m.appConfig.SetPlan(text); err != nil {
+			return fmt.Errorf("failed to save the plan: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkPlanStart starts the cycle at the plan's start time, once a day,
+// when the timer is idle then
+func (m *Model) checkPlanStart(now time.Time) {
+	start, ok := m.pomodoro.Plan.StartTime(now)
+	if !ok || m.teamPeer != nil || now.Before(start) || now.Sub(start) > planStartWindow {
+		return
+	}
+	day := now.Format("2006-01-02")
+	if m.planStartedOn == day {
+		return
+	}
+	m.planStartedOn = day
+	if m.pomodoro.CurrentMode != models.ModeIdle || m.pomodoro.IsPaused || m.isWindingDown() {
+		return
+	}
+	m.start()
+	m.showToast(fmt.Sprintf("It's %s, starting the plan", m.pomodoro.Plan.Start))
+}
+
+// planTimes returns when each block is due to start: from now on for the
+// rest of a running cycle, else from the plan's start time or now. Blocks
+// already done get a zero time.
+func (m *Model) planTimes(now time.Time) []time.Time {
+	blocks := m.pomodoro.Plan.Blocks
+	times := make([]time.Time, len(blocks))
+
+	first, next := 0, now
+	if m.pomodoro.CurrentMode != models.ModeIdle {
+		// The current block may have been edited out of the plan
+		first = m.pomodoro.Block
+		if first >= 0 && first < len(times) {
+			times[first] = now.Add(m.pomodoro.RemainingTime - m.pomodoro.TotalTime)
+		}
+		next = now.Add(m.pomodoro.RemainingTime)
+		first++
+	} else if start, ok := m.pomodoro.Plan.StartTime(now); ok && start.After(now) {
+		next = start
+	}
+	for i := first; i < len(blocks); i++ {
+		times[i] = next
+		next = next.Add(blocks[i].Duration)
+	}
+	return times
+}
+
+// upNextLine shows the next block of the plan on the dashboard, empty when
+// idle or in the last block
+func (m *Model) upNextLine() string {
+	if m.pomodoro.CurrentMode == models.ModeIdle || m.followsTeamHost() {
+		return ""
+	}
+	next := m.pomodoro.Block + 1
+	if next >= len(m.pomodoro.Plan.Blocks) {
+		return "⏭ Last block of the plan"
+	}
+	block := m.pomodoro.Plan.Blocks[next]
+	at := m.planTimes(time.Now())[next]
+	return fmt.Sprintf("⏭ Up next: %s (%s) at %s", block.Label, formatMinutes(int(block.Duration.Minutes())), at.Format("15:04"))
+}
+
+func (m *Model) renderPlan() string {
+	var sb strings.Builder
+
+	menuStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(1, 2).
+		Foreground(lipgloss.Color("#00D9FF"))
+	currentStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FFD93D"))
+	doneStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#666666"))
+
+	sb.WriteString("─── TODAY'S PLAN ───\n\n")
+	if m.followsTeamHost() {
+		sb.WriteString("Following the team host's plan\n")
+		if m.pomodoro.BlockLabel != "" {
+			sb.WriteString(fmt.Sprintf("Now: %s\n", m.pomodoro.BlockLabel))
+		}
+		sb.WriteString("\nesc - Close\n")
+		return menuStyle.Render(sb.String())
+	}
+
+	idle := m.pomodoro.CurrentMode == models.ModeIdle
+	times := m.planTimes(time.Now())
+	if !idle && m.pomodoro.Block < 0 {
+		// Edited out of the plan, which follows it
+		kind := "🍅"
+		if m.pomodoro.CurrentMode != models.ModeFocus {
+			kind = "☕"
+		}
+		line := fmt.Sprintf("%s %-20s %6s", kind, m.pomodoro.BlockLabel, formatMinutes(int(m.pomodoro.TotalTime.Minutes())))
+		sb.WriteString(currentStyle.Render("→ now   "+line) + "\n")
+	}
+	for i, block := range m.pomodoro.Plan.Blocks {
+		kind := "🍅"
+		if block.Kind != models.BlockFocus {
+			kind = "☕"
+		}
+		line := fmt.Sprintf("%s %-20s %6s", kind, block.Label, formatMinutes(int(block.Duration.Minutes())))
+		switch {
+		case !idle && i < m.pomodoro.Block:
+			sb.WriteString(doneStyle.Render("  ✓     "+line) + "\n")
+		case !idle && i == m.pomodoro.Block:
+			sb.WriteString(currentStyle.Render("→ now   "+line) + "\n")
+		default:
+			sb.WriteString(fmt.Sprintf("  %s %s\n", times[i].Format("15:04"), line))
+		}
+	}
+	if len(times) > 0 && idle {
+		end := times[len(times)-1].Add(m.pomodoro.Plan.Blocks[len(times)-1].Duration)
+		sb.WriteString(fmt.Sprintf("\nDone by %s if started ", end.Format("15:04")))
+		if start, ok := m.pomodoro.Plan.StartTime(time.Now()); ok && start.After(time.Now()) {
+			sb.WriteString("at " + m.pomodoro.Plan.Start + "\n")
+		} else {
+			sb.WriteString("now\n")
+		}
+	}
+
+	if m.planInput.active {
+		sb.WriteString(fmt.Sprintf("\nPlan: %s\n", m.planInput.view()))
+		sb.WriteString("e.g. 09:00 deep work 90m, break 15m, code review 45m, long break 30m\n")
+		sb.WriteString("enter - Save (empty for the default cycle) | esc - Cancel\n")
+	} else {
+		sb.WriteString("\ne - Edit | esc - Close\n")
+		sb.WriteString("Changes apply from the next block\n")
+	}
+
+	return menuStyle.Render(sb.String())
+}
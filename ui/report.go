@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"zoneout/stats"
+)
+
+// reportPeriods are the ranges the report panel cycles through
+var reportPeriods = []string{"Today", "This week", "This month"}
+
+// reportRange is [from, to) for the report panel's period
+func (m *Model) reportRange(now time.Time) (from, to time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch m.reportPeriod {
+	case 0:
+		from = today
+	case 1:
+		from = stats.Week(today)
+	default:
+		from = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	}
+	return from, today.AddDate(0, 0, 1)
+}
+
+// openReport shows the focus time per tag, loading the history once
+func (m *Model) openReport() {
+	m.closePanels()
+	records, err := m.appStats.History()
+	if err != nil {
+		m.showToast(err.Error())
+	}
+	m.reportRecords = records
+	m.showReport = true
+}
+
+// handleReportKey handles a key while the report is open, reporting
+// whether it was used
+func (m *Model) handleReportKey(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "tab":
+		m.reportPeriod = (m.reportPeriod + 1) % len(reportPeriods)
+	case "esc", "p":
+		m.showReport = false
+		m.reportRecords = nil
+	default:
+		return false
+	}
+	return true
+}
+
+func (m *Model) renderReport() string {
+	var sb strings.Builder
+
+	menuStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(1, 2).
+		Foreground(lipgloss.Color("#00D9FF"))
+
+	from, to := m.reportRange(time.Now())
+	totals := stats.Totals(m.reportRecords, from, to, stats.ByTag)
+
+	sb.WriteString(fmt.Sprintf("─── FOCUS BY TAG: %s ───\n\n", strings.ToUpper(reportPeriods[m.reportPeriod])))
+	if len(totals) == 0 {
+		sb.WriteString("No focus sessions yet\n")
+	}
+	width := 0
+	for _, total := range totals {
+		width = max(width, len([]rune(total.Name)))
+	}
+	for _, total := range totals {
+		sb.WriteString(fmt.Sprintf("%-*s  %3d  %6s  ⚡ %d/%d\n", width, total.Name, total.Sessions,
+			formatMinutes(total.Minutes), total.Internal, total.External))
+	}
+	if len(totals) > 0 {
+		sum := stats.Sum(m.reportRecords, from, to)
+		sb.WriteString(fmt.Sprintf("\nTotal %s, ⚡ %d internal / %d external interruptions\n",
+			formatMinutes(sum.Minutes), sum.Internal, sum.External))
+	}
+
+	sb.WriteString("\ntab - Next period | esc - Close\n")
+	sb.WriteString("zoneout report for other ranges\n")
+
+	return menuStyle.Render(sb.String())
+}
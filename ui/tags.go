@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"zoneout/control"
+	"zoneout/stats"
+)
+
+// Tag picker: the tags recorded with focus sessions, for per-project
+// reports and timesheets
+
+// openTags shows the tag picker with the tags used before
+func (m *Model) openTags() {
+	records, err := m.appStats.History()
+	if err != nil {
+		m.showToast(err.Error())
+	}
+	// Tags picked for this session first, then the most recently used
+	m.knownTags = append([]string(nil), m.sessionTags...)
+	for _, tag := range stats.RecentTags(records) {
+		if !containsTag(m.knownTags, tag) {
+			m.knownTags = append(m.knownTags, tag)
+		}
+	}
+	m.selectedTag = 0
+	m.closePanels()
+	m.showTags = true
+}
+
+// focusTags are the tags to record with a focus session: those picked
+// plus the active task's projects and tags
+func (m *Model) focusTags() []string {
+	tags := append([]string(nil), m.sessionTags...)
+	if m.taskSource != nil {
+		if t, ok := m.taskSource.Active(); ok {
+			for _, tag := range append(append([]string(nil), t.Projects...), t.Tags...) {
+				if !containsTag(tags, tag) {
+					tags = append(tags, tag)
+				}
+			}
+		}
+	}
+	return tags
+}
+
+// toggleTag adds tag to the session's tags, or removes it
+func (m *Model) toggleTag(tag string) {
+	for i, t := range m.sessionTags {
+		if t == tag {
+			m.sessionTags = append(m.sessionTags[:i], m.sessionTags[i+1:]...)
+			return
+		}
+	}
+	m.sessionTags = append(m.sessionTags, tag)
+	sort.Strings(m.sessionTags)
+}
+
+// handleTagKey handles a key while the tag picker is open, reporting
+// whether it was used
+func (m *Model) handleTagKey(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "up":
+		if m.selectedTag > 0 {
+			m.selectedTag--
+		}
+	case "down":
+		if m.selectedTag < len(m.knownTags)-1 {
+			m.selectedTag++
+		}
+	case "enter":
+		if m.selectedTag < len(m.knownTags) {
+			m.toggleTag(m.knownTags[m.selectedTag])
+			m.tagsChanged()
+		}
+	case "n":
+		m.tagInput.start("")
+	case "c": // clear
+		m.sessionTags = nil
+		m.tagsChanged()
+	case "esc", "#":
+		m.showTags = false
+	default:
+		return false
+	}
+	return true
+}
+
+// handleTagInput adds a new tag, selected right away
+func (m *Model) handleTagInput(msg tea.KeyMsg) {
+	if !m.tagInput.update(msg) {
+		return
+	}
+	tag := strings.Join(strings.Fields(m.tagInput.text), "-")
+	if tag == "" {
+		return
+	}
+	if !containsTag(m.sessionTags, tag) {
+		m.toggleTag(tag)
+		m.tagsChanged()
+	}
+	if !containsTag(m.knownTags, tag) {
+		m.knownTags = append([]string{tag}, m.knownTags...)
+	}
+}
+
+// tagsChanged passes the tags picked on to the daemon when attached to one
+func (m *Model) tagsChanged() {
+	m.forwardToDaemon(control.Request{Command: control.CommandSetTags, Tags: m.sessionTags})
+}
+
+// tagsLine shows the session's tags on the dashboard, empty without tags
+func tagsLine(s control.Status) string {
+	if len(s.Tags) == 0 {
+		return ""
+	}
+	return "🏷  " + strings.Join(s.Tags, ", ")
+}
+
+func (m *Model) renderTags() string {
+	var sb strings.Builder
+
+	menuStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(1, 2).
+		Foreground(lipgloss.Color("#00D9FF"))
+	selectedStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FFD93D"))
+
+	sb.WriteString("─── SESSION TAGS ───\n\n")
+	if len(m.knownTags) == 0 {
+		sb.WriteString("No tags yet, press n to add one\n")
+	}
+	for i, tag := range m.knownTags {
+		prefix := "  "
+		if i == m.selectedTag {
+			prefix = "→ "
+		}
+		check := "[ ]"
+		if containsTag(m.sessionTags, tag) {
+			check = "[x]"
+		}
+		line := fmt.Sprintf("%s %s", check, tag)
+		if i == m.selectedTag {
+			line = selectedStyle.Render(line)
+		}
+		sb.WriteString(prefix + line + "\n")
+	}
+
+	if m.tagInput.active {
+		sb.WriteString(fmt.Sprintf("\nNew tag: %s\n", m.tagInput.view()))
+		sb.WriteString("enter - Add | esc - Cancel\n")
+	} else {
+		sb.WriteString("\nenter - Toggle | n - New | c - Clear | esc - Close\n")
+		sb.WriteString("The active task's projects and tags are added too\n")
+	}
+
+	return menuStyle.Render(sb.String())
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
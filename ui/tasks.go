@@ -0,0 +1,305 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"zoneout/control"
+	"zoneout/stats"
+	"zoneout/tasks"
+)
+
+// Task panel: add, edit, complete and select the task focus sessions are
+// attributed to
+
+// SetTaskSource enables the task panel with the tasks in source
+func (m *Model) SetTaskSource(source tasks.Source) {
+	m.taskSource = source
+	m.refreshTaskTotals()
+	m.syncActiveTask()
+}
+
+// refreshTaskTotals recomputes the pomodoros spent per task from the
+// session history
+func (m *Model) refreshTaskTotals() {
+	records, err := m.appStats.History()
+	if err != nil {
+		m.showToast(err.Error())
+	}
+	m.taskTotals = stats.TotalsByTask(records)
+}
+
+// syncActiveTask reports the active task with timer events
+func (m *Model) syncActiveTask() {
+	m.pomodoro.Task = ""
+	if m.taskSource == nil {
+		return
+	}
+	if t, ok := m.taskSource.Active(); ok {
+		m.pomodoro.Task = t.Title
+	}
+}
+
+// recordFocus adds the focus session that just ended to the stats,
+// attributed to the active task
+func (m *Model) recordFocus() {
+	span := m.pomodoro.LastFocus
+	rec := stats.SessionRecord{
+		Start:   span.Start,
+		End:     span.End,
+		Minutes: int(span.Actual.Round(time.Minute).Minutes()),
+		Tags:    m.focusTags(),
+
+		Interruptions: m.focusInterruptions(span.Start),
+	}
+	m.interruptions = nil
+	if m.taskSource != nil {
+		if t, ok := m.taskSource.Active(); ok {
+			rec.TaskID = t.ID
+			rec.Task = t.Title
+		}
+	}
+	if err := m.appStats.RecordSession(rec); err != nil {
+		m.showToast(err.Error())
+	}
+	if m.orgClock != nil {
+		if _, err := m.orgClock.Append(rec); err != nil {
+			m.showToast(err.Error())
+		}
+	}
+	if rec.TaskID != "" {
+		if err := m.taskSource.AddPomodoro(rec.TaskID); err != nil {
+			m.showToast(err.Error())
+		}
+		total := m.taskTotals[rec.TaskID]
+		total.Sessions++
+		total.Minutes += rec.Minutes
+		m.taskTotals[rec.TaskID] = total
+	}
+}
+
+// selectedTaskID is the task under the cursor, "" when the list is empty
+func (m *Model) selectedTaskID(list []tasks.Task) string {
+	if m.selectedTask < 0 || m.selectedTask >= len(list) {
+		return ""
+	}
+	return list[m.selectedTask].ID
+}
+
+// handleTaskKey handles a key while the task panel is open, reporting
+// whether it was used
+func (m *Model) handleTaskKey(msg tea.KeyMsg) bool {
+	list := m.taskSource.List()
+	id := m.selectedTaskID(list)
+
+	var err error
+	switch msg.String() {
+	case "up":
+		if m.selectedTask > 0 {
+			m.selectedTask--
+		}
+	case "down":
+		if m.selectedTask < len(list)-1 {
+			m.selectedTask++
+		}
+	case "enter": // make the task active, or no task if it already is
+		if id == "" {
+			break
+		}
+		if active, ok := m.taskSource.Active(); ok && active.ID == id {
+			id = ""
+		}
+		err = m.taskSource.SetActive(id)
+		m.syncActiveTask()
+	case "n":
+		m.taskInput.start("")
+		m.editTaskID = ""
+	case "e":
+		if id == "" {
+			break
+		}
+		m.taskInput.start(tasks.Format(m.taskSource, list[m.selectedTask]))
+		m.editTaskID = id
+	case "x": // complete, or reopen a completed task
+		if id == "" {
+			break
+		}
+		err = m.taskSource.SetDone(id, !list[m.selectedTask].Done)
+		m.syncActiveTask()
+	case "+", "=":
+		if id != "" {
+			err = m.taskSource.SetEstimate(id, list[m.selectedTask].Estimate+1)
+		}
+	case "-", "_":
+		if id != "" {
+			err = m.taskSource.SetEstimate(id, list[m.selectedTask].Estimate-1)
+		}
+	case "esc", "t":
+		m.showTasks = false
+	default:
+		return false
+	}
+
+	if err != nil {
+		m.showToast(err.Error())
+	}
+	return true
+}
+
+// taskAddedMsg reports a task added in the background, for the control
+// client that asked for it if reply is set
+type taskAddedMsg struct {
+	task  tasks.Task
+	err   error
+	reply *control.CommandMsg
+}
+
+// addTaskCmd adds a task off the UI goroutine, as an external task command
+// may take a while
+func (m *Model) addTaskCmd(input string, reply *control.CommandMsg) tea.Cmd {
+	source := m.taskSource
+	return func() tea.Msg {
+		t, err := source.Add(input)
+		return taskAddedMsg{task: t, err: err, reply: reply}
+	}
+}
+
+// taskAdded puts the cursor on a task added from the panel, or replies to
+// the client that added it
+func (m *Model) taskAdded(msg taskAddedMsg) {
+	if msg.reply != nil {
+		status := m.status()
+		resp := control.Response{OK: msg.err == nil, Status: &status}
+		if msg.err != nil {
+			resp.Error = msg.err.Error()
+		}
+		msg.reply.Reply(resp)
+		return
+	}
+	if msg.err != nil {
+		m.showToast(msg.err.Error())
+		return
+	}
+	for i, t := range m.taskSource.List() {
+		if t.ID == msg.task.ID {
+			m.selectedTask = i
+		}
+	}
+}
+
+// handleTaskInput edits the title of a new or existing task
+func (m *Model) handleTaskInput(msg tea.KeyMsg) tea.Cmd {
+	if !m.taskInput.update(msg) {
+		return nil
+	}
+	if m.editTaskID == "" {
+		return m.addTaskCmd(m.taskInput.text, nil)
+	}
+	if err := m.taskSource.Edit(m.editTaskID, m.taskInput.text); err != nil {
+		m.showToast(err.Error())
+	}
+	m.syncActiveTask()
+	return nil
+}
+
+// activeTaskLine describes the active task for the dashboard, empty when
+// there is none
+func (m *Model) activeTaskLine(s control.Status) string {
+	if s.Task == "" {
+		return ""
+	}
+	return fmt.Sprintf("📝 %s (%s)", s.Task, m.taskProgress(tasks.Task{Pomodoros: s.TaskPomodoros, Estimate: s.TaskEstimate}))
+}
+
+// taskProgress is pomodoros done against the estimate, e.g. "2/4 🍅"
+func (m *Model) taskProgress(t tasks.Task) string {
+	if t.Estimate > 0 {
+		return fmt.Sprintf("%d/%d 🍅", t.Pomodoros, t.Estimate)
+	}
+	return fmt.Sprintf("%d 🍅", t.Pomodoros)
+}
+
+func (m *Model) renderTasks() string {
+	var sb strings.Builder
+
+	menuStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(1, 2).
+		Foreground(lipgloss.Color("#00D9FF"))
+	doneStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#666666")).
+		Strikethrough(true)
+	selectedStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FFD93D"))
+
+	sb.WriteString("─── TASKS ───\n\n")
+
+	list := m.taskSource.List()
+	if len(list) == 0 {
+		sb.WriteString("No tasks yet, press n to add one\n")
+	}
+	active, _ := m.taskSource.Active()
+	for i, t := range list {
+		prefix := "  "
+		if i == m.selectedTask {
+			prefix = "→ "
+		}
+		check := "[ ]"
+		if t.Done {
+			check = "[x]"
+		} else if t.ID == active.ID {
+			check = "[●]"
+		}
+		line := check + " "
+		if t.Priority != "" {
+			line += "(" + t.Priority + ") "
+		}
+		line += t.Title
+		for _, project := range t.Projects {
+			line += " +" + project
+		}
+		for _, context := range t.Contexts {
+			line += " @" + context
+		}
+		for _, tag := range t.Tags {
+			line += " #" + tag
+		}
+		line += "  " + m.taskProgress(t)
+		if minutes := m.taskTotals[t.ID].Minutes; minutes > 0 {
+			line += "  " + formatMinutes(minutes)
+		}
+
+		switch {
+		case i == m.selectedTask:
+			line = selectedStyle.Render(line)
+		case t.Done:
+			line = doneStyle.Render(line)
+		}
+		sb.WriteString(prefix + line + "\n")
+	}
+
+	if m.taskInput.active {
+		label := "New task"
+		if m.editTaskID != "" {
+			label = "Edit task"
+		}
+		sb.WriteString(fmt.Sprintf("\n%s (~N sets the estimate): %s\n", label, m.taskInput.view()))
+		sb.WriteString("enter - Save | esc - Cancel\n")
+	} else {
+		sb.WriteString("\nenter - Work on it | n - New | e - Edit | x - Done | +/- - Estimate | esc - Close\n")
+	}
+
+	return menuStyle.Render(sb.String())
+}
+
+// formatMinutes shows a focus total such as "1h25m"
+func formatMinutes(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
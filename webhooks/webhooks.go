@@ -0,0 +1,403 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"zoneout/config"
+	"zoneout/models"
+)
+
+// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of the
+// request body, keyed with the webhook's secret
+const SignatureHeader = "X-Zoneout-Signature"
+
+// QueueFile is where undelivered events are kept across restarts
+const QueueFile = "webhook_queue.json"
+
+// Sign returns the SignatureHeader value for body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// delivery is one event on its way to one webhook
+type delivery struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Event       string    `json:"event"`
+	Body        string    `json:"body"`                // Exactly as signed and sent
+	Signature   string    `json:"signature,omitempty"` // Stored instead of the secret
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Dispatcher delivers events to webhooks in the background. Events are
+// queued on disk first and retried with exponential backoff, so they
+// survive being offline and restarts.
+type Dispatcher struct {
+	webhooks     []config.Webhook
+	queuePath    string
+	queue        []delivery
+	client       *http.Client
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	maxAge       time.Duration // Deliveries older than this are dropped
+	pendingError error         // Failure not yet reported to the UI
+	wake         chan struct{}
+	ctx          context.Context
+	cancel       context.CancelFunc
+	done         chan struct{}
+	mu           sync.Mutex
+}
+
+// New creates a dispatcher for webhooks, loading any events left in the
+// queue file under configDir. Call Start to begin delivering. Webhooks with
+// an invalid URL are skipped and a queue file that can't be read is moved
+// aside; the dispatcher is usable either way and the error says what
+// happened.
+func New(configDir string, webhooks []config.Webhook) (*Dispatcher, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		queuePath:  filepath.Join(configDir, QueueFile),
+		client:     &http.Client{Timeout: 10 * time.Second},
+		minBackoff: 5 * time.Second,
+		maxBackoff: 30 * time.Minute,
+		maxAge:     24 * time.Hour,
+		wake:       make(chan struct{}, 1),
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+	var errs []error
+	for _, w := range webhooks {
+		if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = append(errs, fmt.Errorf("invalid webhook URL %q, skipped", w.URL))
+			continue
+		}
+		d.webhooks = append(d.webhooks, w)
+	}
+	if err := d.load(); err != nil {
+		errs = append(errs, err)
+	}
+	return d, errors.Join(errs...)
+}
+
+// Start delivers queued events until Close is called
+func (d *Dispatcher) Start() {
+	go d.run()
+}
+
+// Close stops delivering, leaving undelivered events in the queue file
+func (d *Dispatcher) Close() {
+	d.cancel()
+	<-d.done
+}
+
+// Fire queues ev for every webhook subscribed to it. It has the
+// models.Listener signature so it can be registered on a Pomodoro.
+func (d *Dispatcher) Fire(ev models.PhaseEvent) {
+	body, err := json.Marshal(ev.Payload())
+	if err != nil {
+		d.recordError(err)
+		return
+	}
+
+	d.mu.Lock()
+	queued := false
+	for _, w := range d.webhooks {
+		if !subscribed(w, ev.Event) {
+			continue
+		}
+		dl := delivery{
+			ID:          newID(),
+			URL:         w.URL,
+			Event:       string(ev.Event),
+			Body:        string(body),
+			NextAttempt: ev.Time,
+			CreatedAt:   ev.Time,
+		}
+		if w.Secret != "" {
+			dl.Signature = Sign(w.Secret, body)
+		}
+		d.queue = append(d.queue, dl)
+		queued = true
+	}
+	if queued {
+		if err := d.saveLocked(); err != nil {
+			d.pendingError = err
+		}
+	}
+	d.mu.Unlock()
+
+	if queued {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Pending returns the number of events waiting to be delivered
+func (d *Dispatcher) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.queue)
+}
+
+// TakeError returns a delivery failure that has not been reported yet and
+// clears it, so the UI shows each failure once
+func (d *Dispatcher) TakeError() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	err := d.pendingError
+	d.pendingError = nil
+	return err
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	for {
+		wait := d.deliverDue()
+		timer := time.NewTimer(wait)
+		select {
+		case <-d.ctx.Done():
+			timer.Stop()
+			return
+		case <-d.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// deliverDue attempts the deliveries that are due and returns how long to
+// wait before the next one is. Each webhook gets its events in the order
+// they happened: only the oldest one queued for it is sent, and the others
+// wait while it is retried.
+func (d *Dispatcher) deliverDue() time.Duration {
+	for d.ctx.Err() == nil {
+		now := time.Now()
+		d.mu.Lock()
+		var due []delivery
+		for _, dl := range d.headsLocked() {
+			if !dl.NextAttempt.After(now) {
+				due = append(due, dl)
+			}
+		}
+		d.mu.Unlock()
+		if len(due) == 0 {
+			break
+		}
+
+		for _, dl := range due {
+			if d.ctx.Err() != nil {
+				break
+			}
+			// Send without holding the lock so Fire never waits on the network
+			retry, err := d.send(dl)
+			d.finish(dl, err, retry)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	wait := time.Hour
+	for _, dl := range d.headsLocked() {
+		if until := time.Until(dl.NextAttempt); until < wait {
+			wait = until
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// headsLocked returns the oldest delivery queued for each webhook URL
+func (d *Dispatcher) headsLocked() []delivery {
+	var heads []delivery
+	seen := make(map[string]bool)
+	for _, dl := range d.queue {
+		if !seen[dl.URL] {
+			seen[dl.URL] = true
+			heads = append(heads, dl)
+		}
+	}
+	return heads
+}
+
+// send POSTs one delivery, reporting whether a failure is worth retrying
+func (d *Dispatcher) send(dl delivery) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, dl.URL, strings.NewReader(dl.Body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "zoneout")
+	req.Header.Set("X-Zoneout-Event", dl.Event)
+	req.Header.Set("X-Zoneout-Delivery", dl.ID)
+	if dl.Signature != "" {
+		req.Header.Set(SignatureHeader, dl.Signature)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("server responded %s", resp.Status)
+	default:
+		// The endpoint rejected the event, sending it again won't help
+		return false, fmt.Errorf("server responded %s", resp.Status)
+	}
+}
+
+// finish removes a delivery that succeeded or failed for good, and
+// schedules a retry for the rest
+func (d *Dispatcher) finish(dl delivery, err error, retry bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	i := d.indexLocked(dl.ID)
+	if i < 0 {
+		return
+	}
+	if err != nil && d.ctx.Err() != nil {
+		// Interrupted by Close, not the endpoint's fault
+		return
+	}
+
+	if err == nil {
+		d.queue = append(d.queue[:i], d.queue[i+1:]...)
+	} else {
+		host := dl.URL
+		if u, perr := url.Parse(dl.URL); perr == nil {
+			host = u.Host
+		}
+		if retry && time.Since(dl.CreatedAt) < d.maxAge {
+			d.queue[i].Attempts++
+			d.queue[i].NextAttempt = time.Now().Add(d.backoff(d.queue[i].Attempts))
+			// Report the first failure, not every retry
+			if d.queue[i].Attempts == 1 {
+				d.pendingError = fmt.Errorf("webhook %s: %v (will retry)", host, err)
+			}
+		} else {
+			d.queue = append(d.queue[:i], d.queue[i+1:]...)
+			d.pendingError = fmt.Errorf("webhook %s: %v (dropped %s event)", host, err, dl.Event)
+		}
+	}
+
+	if err := d.saveLocked(); err != nil {
+		d.pendingError = err
+	}
+}
+
+// backoff doubles the delay with every attempt, up to maxBackoff
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.minBackoff
+	for i := 1; i < attempts && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.maxBackoff {
+		delay = d.maxBackoff
+	}
+	return delay
+}
+
+func (d *Dispatcher) indexLocked(id string) int {
+	for i, dl := range d.queue {
+		if dl.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (d *Dispatcher) load() error {
+	data, err := os.ReadFile(d.queuePath)
+	if err != nil {
+		// No queue yet
+		return nil
+	}
+	if err := json.Unmarshal(data, &d.queue); err != nil {
+		d.queue = nil
+		// Keep it for a look, but don't let it stop new events
+		bad := d.queuePath + ".bad"
+		if rerr := os.Rename(d.queuePath, bad); rerr != nil {
+			return fmt.Errorf("failed to parse webhook queue: %w", err)
+		}
+		return fmt.Errorf("failed to parse webhook queue, moved it to %s: %w", bad, err)
+	}
+	return nil
+}
+
+// saveLocked writes the queue file, removing it when the queue is empty;
+// d.mu must be held
+func (d *Dispatcher) saveLocked() error {
+	if len(d.queue) == 0 {
+		if err := os.Remove(d.queuePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove webhook queue: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(d.queue, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook queue: %w", err)
+	}
+	tmpPath := d.queuePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write webhook queue: %w", err)
+	}
+	if err := os.Rename(tmpPath, d.queuePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write webhook queue: %w", err)
+	}
+	return nil
+}
+
+func (d *Dispatcher) recordError(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pendingError = err
+}
+
+func subscribed(w config.Webhook, event models.Event) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if models.Event(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
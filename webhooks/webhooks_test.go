@@ -0,0 +1,307 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"zoneout/config"
+	"zoneout/models"
+)
+
+// request is what the stand-in server received
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// standIn is a local webhook endpoint answering with the status codes
+// queued in statuses, then 200
+type standIn struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	received []request
+}
+
+func newStandIn(t *testing.T, statuses ...int) *standIn {
+	s := &standIn{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.received = append(s.received, request{header: r.Header.Clone(), body: body})
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *standIn) requests() []request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]request(nil), s.received...)
+}
+
+func (s *standIn) setStatuses(statuses ...int) {
+	s.mu.Lock()
+	s.statuses = statuses
+	s.mu.Unlock()
+}
+
+func newDispatcher(t *testing.T, dir string, hooks ...config.Webhook) *Dispatcher {
+	t.Helper()
+	d, err := New(dir, hooks)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	d.minBackoff = 10 * time.Millisecond
+	return d
+}
+
+func focusStart() models.PhaseEvent {
+	return models.PhaseEvent{
+		Event:         models.EventFocusStart,
+		Mode:          "FOCUS",
+		Session:       1,
+		TotalSessions: 3,
+		Duration:      25 * time.Minute,
+		Remaining:     25 * time.Minute,
+		Time:          time.Now(),
+	}
+}
+
+// waitFor polls cond until it holds or a few seconds pass
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSignature(t *testing.T) {
+	server := newStandIn(t)
+	d := newDispatcher(t, t.TempDir(), config.Webhook{URL: server.URL, Secret: "hunter2"})
+	d.Start()
+	defer d.Close()
+
+	d.Fire(focusStart())
+	waitFor(t, "the delivery", func() bool { return len(server.requests()) == 1 })
+
+	req := server.requests()[0]
+	mac := hmac.New(sha256.New, []byte("hunter2"))
+	mac.Write(req.body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := req.header.Get(SignatureHeader); got != want {
+		t.Errorf("%s = %q, want %q", SignatureHeader, got, want)
+	}
+	if got := req.header.Get("X-Zoneout-Event"); got != string(models.EventFocusStart) {
+		t.Errorf("X-Zoneout-Event = %q, want %q", got, models.EventFocusStart)
+	}
+
+	var payload models.EventPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("body: %v", err)
+	}
+	if payload.Event != "focus_start" || payload.Mode != "focus" || payload.PlannedSeconds != 1500 {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestNoSignatureWithoutSecret(t *testing.T) {
+	server := newStandIn(t)
+	d := newDispatcher(t, t.TempDir(), config.Webhook{URL: server.URL})
+	d.Start()
+	defer d.Close()
+
+	d.Fire(focusStart())
+	waitFor(t, "the delivery", func() bool { return len(server.requests()) == 1 })
+	if got := server.requests()[0].header.Get(SignatureHeader); got != "" {
+		t.Errorf("%s = %q without a secret", SignatureHeader, got)
+	}
+}
+
+func TestRetryOnServerError(t *testing.T) {
+	server := newStandIn(t, http.StatusInternalServerError, http.StatusBadGateway)
+	d := newDispatcher(t, t.TempDir(), config.Webhook{URL: server.URL})
+	d.Start()
+	defer d.Close()
+
+	d.Fire(focusStart())
+	waitFor(t, "the queue to empty", func() bool { return d.Pending() == 0 && len(server.requests()) == 3 })
+
+	requests := server.requests()
+	id := requests[0].header.Get("X-Zoneout-Delivery")
+	for i, req := range requests {
+		if got := req.header.Get("X-Zoneout-Delivery"); got != id {
+			t.Errorf("attempt %d delivery ID = %q, want %q", i+1, got, id)
+		}
+		if string(req.body) != string(requests[0].body) {
+			t.Errorf("attempt %d body changed", i+1)
+		}
+	}
+	if err := d.TakeError(); err == nil || !strings.Contains(err.Error(), "will retry") {
+		t.Errorf("TakeError = %v, want the first failure", err)
+	}
+}
+
+func TestDropOnClientError(t *testing.T) {
+	server := newStandIn(t, http.StatusBadRequest)
+	d := newDispatcher(t, t.TempDir(), config.Webhook{URL: server.URL})
+	d.Start()
+	defer d.Close()
+
+	d.Fire(focusStart())
+	waitFor(t, "the queue to empty", func() bool { return d.Pending() == 0 })
+
+	// Give a wrongful retry the time to happen
+	time.Sleep(5 * d.minBackoff)
+	if n := len(server.requests()); n != 1 {
+		t.Errorf("%d attempts, want 1", n)
+	}
+	if err := d.TakeError(); err == nil || !strings.Contains(err.Error(), "dropped") {
+		t.Errorf("TakeError = %v, want the dropped event", err)
+	}
+}
+
+func TestSubscribedEvents(t *testing.T) {
+	server := newStandIn(t)
+	d := newDispatcher(t, t.TempDir(), config.Webhook{URL: server.URL, Events: []string{"focus_end"}})
+
+	d.Fire(focusStart())
+	if n := d.Pending(); n != 0 {
+		t.Errorf("%d queued for an event not subscribed to", n)
+	}
+	ev := focusStart()
+	ev.Event = models.EventFocusEnd
+	d.Fire(ev)
+	if n := d.Pending(); n != 1 {
+		t.Errorf("%d queued, want 1", n)
+	}
+}
+
+func TestQueueSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	server := newStandIn(t, http.StatusServiceUnavailable)
+	first := newDispatcher(t, dir, config.Webhook{URL: server.URL})
+	first.minBackoff = time.Hour // Not again before the restart
+	first.Start()
+
+	first.Fire(focusStart())
+	waitFor(t, "the first attempt", func() bool { return len(server.requests()) == 1 })
+	waitFor(t, "the retry to be scheduled", func() bool { return first.TakeError() != nil })
+	first.Close()
+	if _, err := os.Stat(filepath.Join(dir, QueueFile)); err != nil {
+		t.Fatalf("queue file: %v", err)
+	}
+
+	server.setStatuses()
+	second := newDispatcher(t, dir, config.Webhook{URL: server.URL})
+	if n := second.Pending(); n != 1 {
+		t.Fatalf("%d pending after the restart, want 1", n)
+	}
+	if second.queue[0].Attempts != 1 {
+		t.Errorf("attempts = %d after the restart, want 1", second.queue[0].Attempts)
+	}
+	second.queue[0].NextAttempt = time.Now() // Due now rather than in an hour
+	second.Start()
+	defer second.Close()
+
+	waitFor(t, "the queue to empty", func() bool { return second.Pending() == 0 })
+	requests := server.requests()
+	if len(requests) != 2 {
+		t.Fatalf("%d requests, want 2", len(requests))
+	}
+	if a, b := requests[0].header.Get("X-Zoneout-Delivery"), requests[1].header.Get("X-Zoneout-Delivery"); a != b {
+		t.Errorf("delivery ID changed across the restart: %q, %q", a, b)
+	}
+	if _, err := os.Stat(filepath.Join(dir, QueueFile)); !os.IsNotExist(err) {
+		t.Errorf("queue file left behind: %v", err)
+	}
+}
+
+func TestCorruptQueueMovedAside(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, QueueFile)
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := New(dir, nil)
+	if err == nil {
+		t.Error("New accepted a corrupt queue silently")
+	}
+	if d == nil {
+		t.Fatal("New returned no dispatcher")
+	}
+	if d.Pending() != 0 {
+		t.Errorf("%d pending from a corrupt queue", d.Pending())
+	}
+	if _, err := os.Stat(path + ".bad"); err != nil {
+		t.Errorf("corrupt queue not moved aside: %v", err)
+	}
+}
+
+func TestInvalidURLSkipped(t *testing.T) {
+	server := newStandIn(t)
+	d, err := New(t.TempDir(), []config.Webhook{{URL: "ftp://example.com"}, {URL: server.URL}})
+	if err == nil || !strings.Contains(err.Error(), "ftp://example.com") {
+		t.Errorf("New error = %v, want the invalid URL", err)
+	}
+	if d == nil {
+		t.Fatal("New returned no dispatcher")
+	}
+	d.Fire(focusStart())
+	if n := d.Pending(); n != 1 {
+		t.Errorf("%d queued, want 1 for the valid webhook", n)
+	}
+}
+
+func TestOrderKeptAcrossRetries(t *testing.T) {
+	server := newStandIn(t, http.StatusServiceUnavailable)
+	other := newStandIn(t)
+	d := newDispatcher(t, t.TempDir(), config.Webhook{URL: server.URL}, config.Webhook{URL: other.URL})
+	d.minBackoff = 100 * time.Millisecond
+
+	d.Fire(focusStart())
+	end := focusStart()
+	end.Event = models.EventFocusEnd
+	d.Fire(end)
+	d.Start()
+	defer d.Close()
+
+	// The other webhook isn't held up by the retries
+	waitFor(t, "the other webhook", func() bool { return len(other.requests()) == 2 })
+	if n := len(server.requests()); n != 1 {
+		t.Errorf("%d requests before the retry, want 1", n)
+	}
+
+	waitFor(t, "the queue to empty", func() bool { return d.Pending() == 0 })
+	var events []string
+	for _, req := range server.requests() {
+		events = append(events, req.header.Get("X-Zoneout-Event"))
+	}
+	want := []string{"focus_start", "focus_start", "focus_end"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}